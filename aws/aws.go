@@ -5,23 +5,78 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hailocab/go-service-layer/config"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sns"
 	log "github.com/cihub/seelog"
 )
 
+const (
+	// AuthDuration is how long the roles assumed by Auth last
+	AuthDuration = time.Hour
+
+	// AuthExpiryWindow is how long before expiry assumed roles are renewed
+	AuthExpiryWindow = 5 * time.Minute
+)
+
 var (
 	// DefaultAccount ID of default account to operate on
 	DefaultAccount = "864806739507"
 
 	accounts []Account
+
+	// configs caches assumed roles by account ID
+	configs     = map[string]*assumedConfig{}
+	configsLock sync.Mutex
+
+	// accountLocks serialise assuming the role of each account, so one
+	// slow account doesn't hold up the others
+	accountLocks = map[string]*sync.Mutex{}
+
+	// now is replaced in tests
+	now = time.Now
 )
 
+// UnknownAccountError is returned for accounts that aren't configured
+type UnknownAccountError struct {
+	ID string
+}
+
+func (e *UnknownAccountError) Error() string {
+	return fmt.Sprintf("Account %q is not configured", e.ID)
+}
+
+// NoSuchObjectError is returned when an S3 object, or the requested
+// version of it, doesn't exist
+type NoSuchObjectError struct {
+	Bucket  string
+	Key     string
+	Version string
+}
+
+func (e *NoSuchObjectError) Error() string {
+	if len(e.Version) > 0 {
+		return fmt.Sprintf("Object '%s/%s' has no version %q", e.Bucket, e.Key, e.Version)
+	}
+
+	return fmt.Sprintf("Object '%s/%s' doesn't exist", e.Bucket, e.Key)
+}
+
+type assumedConfig struct {
+	config  *aws.Config
+	expires time.Time
+}
+
 // Init triggers a config load
 func Init() {
 	var err error
@@ -30,19 +85,84 @@ func Init() {
 	if err != nil {
 		panic(err)
 	}
+
+	if err := loadCredentialsConfig(); err != nil {
+		panic(err)
+	}
 }
 
-// Auth assumes a role specified
-func Auth(accountID string) (*aws.Config, error) {
-	var account Account
+// GetAccount returns the configured account with the given ID
+func GetAccount(accountID string) (*Account, error) {
 	for _, a := range accounts {
 		if a.ID == accountID {
-			account = a
-			break
+			account := a
+			return &account, nil
 		}
 	}
 
-	return account.AssumeRole(randString(), 3600)
+	return nil, &UnknownAccountError{ID: accountID}
+}
+
+// Auth assumes the role of an account. The assumed role is reused until
+// it's about to expire
+func Auth(accountID string) (*aws.Config, error) {
+	account, err := GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := accountLock(accountID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if c := cachedConfig(accountID); c != nil {
+		return c, nil
+	}
+
+	config, err := account.AssumeRole(randString(), AuthDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := config.Credentials.Get(); err != nil {
+		return nil, fmt.Errorf("Unable to assume role for account %q: %v", accountID, err)
+	}
+
+	configsLock.Lock()
+	configs[accountID] = &assumedConfig{
+		config:  config,
+		expires: now().Add(AuthDuration - AuthExpiryWindow),
+	}
+	configsLock.Unlock()
+
+	return config, nil
+}
+
+// accountLock returns the lock roles of an account are assumed under
+func accountLock(accountID string) *sync.Mutex {
+	configsLock.Lock()
+	defer configsLock.Unlock()
+
+	lock, ok := accountLocks[accountID]
+	if !ok {
+		lock = &sync.Mutex{}
+		accountLocks[accountID] = lock
+	}
+
+	return lock
+}
+
+// cachedConfig returns the assumed role of an account if it isn't about
+// to expire
+func cachedConfig(accountID string) *aws.Config {
+	configsLock.Lock()
+	defer configsLock.Unlock()
+
+	if c, ok := configs[accountID]; ok && now().Before(c.expires) {
+		return c.config
+	}
+
+	return nil
 }
 
 // Credentials returns a credentials struct
@@ -62,6 +182,17 @@ func Credentials() (credentials.Value, error) {
 
 // GetS3Object returns an object from s3
 func GetS3Object(bucket string, key string) (io.ReadCloser, error) {
+	obj, err := GetS3ObjectVersion(bucket, key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return obj.Body, nil
+}
+
+// GetS3ObjectVersion returns a version of an object from s3, the latest
+// version if version is empty
+func GetS3ObjectVersion(bucket string, key string, version string) (*S3Object, error) {
 	config, err := Auth(DefaultAccount)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to auth: %v", err)
@@ -69,23 +200,117 @@ func GetS3Object(bucket string, key string) (io.ReadCloser, error) {
 
 	svc := s3.New(session.New(), config)
 
-	resp, err := svc.GetObject(&s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
-	})
+	}
+
+	if len(version) > 0 {
+		input.VersionId = aws.String(version)
+	}
+
+	resp, err := svc.GetObject(input)
+	if isNoSuchObject(err) {
+		return nil, &NoSuchObjectError{Bucket: bucket, Key: key, Version: version}
+	}
 
 	if err != nil {
 		return nil, fmt.Errorf("Unable to fetch '%s/%s': %v", bucket, key, err)
 	}
 
-	return resp.Body, nil
+	return &S3Object{
+		Body:    resp.Body,
+		Key:     key,
+		Version: aws.StringValue(resp.VersionId),
+		ETag:    strings.Trim(aws.StringValue(resp.ETag), `"`),
+	}, nil
+}
+
+// PutS3Object uploads an object to s3
+func PutS3Object(bucket string, key string, body io.ReadSeeker) (*S3Object, error) {
+	config, err := Auth(DefaultAccount)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to auth: %v", err)
+	}
+
+	svc := s3.New(session.New(), config)
+
+	resp, err := svc.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to upload '%s/%s': %v", bucket, key, err)
+	}
+
+	return &S3Object{
+		Key:     key,
+		Version: aws.StringValue(resp.VersionId),
+		ETag:    strings.Trim(aws.StringValue(resp.ETag), `"`),
+	}, nil
 }
 
-// UploadPart takes a slice of data and appends it to a file
-func UploadPart(bucket string, path string, part int64, body io.ReadSeeker) error {
+// ListS3Objects lists every object in bucket below prefix
+func ListS3Objects(bucket string, prefix string) ([]S3ObjectInfo, error) {
 	config, err := Auth(DefaultAccount)
 	if err != nil {
-		return fmt.Errorf("Unable to auth: %v", err)
+		return nil, fmt.Errorf("Unable to auth: %v", err)
+	}
+
+	svc := s3.New(session.New(), config)
+
+	var objects []S3ObjectInfo
+	err = svc.ListObjectsPages(&s3.ListObjectsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, o := range page.Contents {
+			objects = append(objects, S3ObjectInfo{
+				Key:          aws.StringValue(o.Key),
+				Size:         aws.Int64Value(o.Size),
+				LastModified: aws.TimeValue(o.LastModified),
+			})
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list '%s/%s': %v", bucket, prefix, err)
+	}
+
+	return objects, nil
+}
+
+// CreateMultipartUpload starts a multipart upload, returning its ID
+func CreateMultipartUpload(bucket string, path string) (string, error) {
+	config, err := Auth(DefaultAccount)
+	if err != nil {
+		return "", fmt.Errorf("Unable to auth: %v", err)
+	}
+
+	svc := s3.New(session.New(), config)
+
+	resp, err := svc.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(path),
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("Unable to start upload of '%s/%s': %v", bucket, path, err)
+	}
+
+	return aws.StringValue(resp.UploadId), nil
+}
+
+// UploadPart takes a slice of data and appends it to a multipart upload,
+// returning the part's ETag
+func UploadPart(bucket string, path string, uploadID string, part int64, body io.ReadSeeker) (string, error) {
+	config, err := Auth(DefaultAccount)
+	if err != nil {
+		return "", fmt.Errorf("Unable to auth: %v", err)
 	}
 
 	log.Debugf("Trying to save part %d to: '%s/%s'", part, bucket, path)
@@ -96,18 +321,119 @@ func UploadPart(bucket string, path string, part int64, body io.ReadSeeker) erro
 		Key:        aws.String(path),
 		PartNumber: aws.Int64(part),
 		Body:       body,
-		UploadId:   aws.String("bakery"),
+		UploadId:   aws.String(uploadID),
 	})
 
 	if err != nil {
-		return fmt.Errorf("Unable to save part %d for %q", part, path)
+		return "", fmt.Errorf("Unable to save part %d for %q: %v", part, path, err)
 	}
 
 	log.Debugf("UploadPart resp: %v", resp)
 
+	return aws.StringValue(resp.ETag), nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into an object
+func CompleteMultipartUpload(bucket string, path string, uploadID string, parts []S3Part) (*S3Object, error) {
+	config, err := Auth(DefaultAccount)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to auth: %v", err)
+	}
+
+	svc := s3.New(session.New(), config)
+
+	completed := make([]*s3.CompletedPart, len(parts))
+	for i, p := range parts {
+		completed[i] = &s3.CompletedPart{
+			ETag:       aws.String(p.ETag),
+			PartNumber: aws.Int64(p.Number),
+		}
+	}
+
+	resp, err := svc.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(path),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to complete upload of '%s/%s': %v", bucket, path, err)
+	}
+
+	return &S3Object{
+		Key:     path,
+		Version: aws.StringValue(resp.VersionId),
+		ETag:    strings.Trim(aws.StringValue(resp.ETag), `"`),
+	}, nil
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts
+func AbortMultipartUpload(bucket string, path string, uploadID string) error {
+	config, err := Auth(DefaultAccount)
+	if err != nil {
+		return fmt.Errorf("Unable to auth: %v", err)
+	}
+
+	svc := s3.New(session.New(), config)
+
+	_, err = svc.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(path),
+		UploadId: aws.String(uploadID),
+	})
+
+	if err != nil {
+		return fmt.Errorf("Unable to abort upload of '%s/%s': %v", bucket, path, err)
+	}
+
+	return nil
+}
+
+// PublishSNS publishes a message to an SNS topic as an account
+func PublishSNS(accountID string, topic string, message string) error {
+	config, err := Auth(accountID)
+	if err != nil {
+		return fmt.Errorf("Unable to auth: %v", err)
+	}
+
+	// Topics are published to in their own region
+	// arn:aws:sns:<region>:<account>:<name>
+	if parts := strings.Split(topic, ":"); len(parts) == 6 {
+		config = config.Copy().WithRegion(parts[3])
+	}
+
+	svc := sns.New(session.New(), config)
+
+	_, err = svc.Publish(&sns.PublishInput{
+		TopicArn: aws.String(topic),
+		Message:  aws.String(message),
+	})
+
+	if err != nil {
+		return fmt.Errorf("Unable to publish to %q: %v", topic, err)
+	}
+
 	return nil
 }
 
+// isNoSuchObject reports whether err is S3 saying an object or version
+// doesn't exist
+func isNoSuchObject(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+		return true
+	}
+
+	if awsErr, ok := err.(awserr.Error); ok {
+		switch awsErr.Code() {
+		case "NoSuchKey", "NoSuchVersion":
+			return true
+		}
+	}
+
+	return false
+}
+
 func loadAccountInfo() ([]Account, error) {
 	accountConfig := config.AtPath(
 		"hailo",
@@ -127,7 +453,10 @@ func loadAccountInfo() ([]Account, error) {
 	return accs, nil
 }
 
-func LoadEncryptedAccountInfo() (map[string]string, error) {
+// LoadEncryptedAccountInfo returns the static credentials for an account.
+// Credentials are keyed by account ID, the default account falls back to
+// the "dev" credentials
+func LoadEncryptedAccountInfo(accountID string) (map[string]string, error) {
 	credentials, err := config.AtPath(
 		"hailo",
 		"service",
@@ -139,15 +468,18 @@ func LoadEncryptedAccountInfo() (map[string]string, error) {
 		return map[string]string{}, err
 	}
 
-	log.Infof("Creds: %#v", credentials)
-
-	dev := credentials.AtPath("dev").AsStringMap()
+	creds := credentials.AtPath(accountID).AsStringMap()
+	if len(creds) == 0 && accountID == DefaultAccount {
+		creds = credentials.AtPath("dev").AsStringMap()
+	}
 
-	log.Infof("dev: %#v", dev)
+	if len(creds["aws_access_key_id"]) == 0 || len(creds["aws_secret_access_key"]) == 0 {
+		return map[string]string{}, fmt.Errorf("No credentials configured for account %q", accountID)
+	}
 
 	return map[string]string{
-		"aws_access_key_id":     dev["aws_access_key_id"],
-		"aws_secret_access_key": dev["aws_secret_access_key"],
+		"aws_access_key_id":     creds["aws_access_key_id"],
+		"aws_secret_access_key": creds["aws_secret_access_key"],
 	}, nil
 }
 
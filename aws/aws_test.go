@@ -0,0 +1,236 @@
+package aws
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// fakeSTS records the roles assumed through it. Assuming a role in
+// blocked waits until its channel is closed
+type fakeSTS struct {
+	sync.Mutex
+	inputs  []*sts.AssumeRoleInput
+	blocked map[string]chan struct{}
+}
+
+func (f *fakeSTS) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	f.Lock()
+	block := f.blocked[aws.StringValue(input.RoleArn)]
+	f.Unlock()
+
+	if block != nil {
+		<-block
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	f.inputs = append(f.inputs, input)
+	duration := time.Duration(aws.Int64Value(input.DurationSeconds)) * time.Second
+
+	return &sts.AssumeRoleOutput{
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("AKID"),
+			SecretAccessKey: aws.String("SECRET"),
+			SessionToken:    aws.String("TOKEN"),
+			Expiration:      aws.Time(time.Now().Add(duration)),
+		},
+	}, nil
+}
+
+func (f *fakeSTS) calls() int {
+	f.Lock()
+	defer f.Unlock()
+
+	return len(f.inputs)
+}
+
+// withFakeSTS assumes roles with a fake STS client and a fake clock. The
+// returned func restores the package as it was
+func withFakeSTS(t *testing.T) (*fakeSTS, *time.Time, func()) {
+	fake := &fakeSTS{}
+	clock := time.Now()
+
+	origAccounts, origConfigs, origLocks := accounts, configs, accountLocks
+	origClient, origNow := newSTSClient, now
+
+	accounts = []Account{
+		{ID: "123", Regions: []string{"eu-west-1"}, SNSRole: "arn:aws:iam::123:role/bakery"},
+		{ID: "456", Regions: []string{"us-east-1"}, SNSRole: "arn:aws:iam::456:role/bakery"},
+	}
+	configs = map[string]*assumedConfig{}
+	accountLocks = map[string]*sync.Mutex{}
+	newSTSClient = func() stscreds.AssumeRoler { return fake }
+	now = func() time.Time { return clock }
+
+	return fake, &clock, func() {
+		accounts, configs, accountLocks = origAccounts, origConfigs, origLocks
+		newSTSClient, now = origClient, origNow
+	}
+}
+
+func TestUnknownAccount(t *testing.T) {
+	fake, _, restore := withFakeSTS(t)
+	defer restore()
+
+	_, err := Auth("789")
+	if _, ok := err.(*UnknownAccountError); !ok {
+		t.Fatalf("Expected an unknown account error, got %v", err)
+	}
+
+	if fake.calls() != 0 {
+		t.Fatalf("Expected no roles to be assumed, got %d", fake.calls())
+	}
+}
+
+func TestAssumeRoleOptions(t *testing.T) {
+	fake, clock, restore := withFakeSTS(t)
+	defer restore()
+
+	account, err := GetAccount("123")
+	if err != nil {
+		t.Fatalf("Unable to get account: %v", err)
+	}
+
+	creds, expires, err := account.sessionCredentials("bakery-abc", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Unable to get credentials: %v", err)
+	}
+
+	if creds["aws_session_token"] != "TOKEN" {
+		t.Errorf("Expected the session token to be set, got %q", creds["aws_session_token"])
+	}
+
+	if !expires.Equal(clock.Add(30 * time.Minute)) {
+		t.Errorf("Expected the credentials to expire with the session, got %v", expires)
+	}
+
+	if fake.calls() != 1 {
+		t.Fatalf("Expected 1 role to be assumed, got %d", fake.calls())
+	}
+
+	input := fake.inputs[0]
+	if aws.StringValue(input.RoleArn) != "arn:aws:iam::123:role/bakery" {
+		t.Errorf("Unexpected role %q", aws.StringValue(input.RoleArn))
+	}
+
+	if aws.StringValue(input.RoleSessionName) != "bakery-abc" {
+		t.Errorf("Unexpected session name %q", aws.StringValue(input.RoleSessionName))
+	}
+
+	if aws.Int64Value(input.DurationSeconds) != 1800 {
+		t.Errorf("Unexpected duration %ds", aws.Int64Value(input.DurationSeconds))
+	}
+}
+
+func TestAuthCache(t *testing.T) {
+	fake, clock, restore := withFakeSTS(t)
+	defer restore()
+
+	first, err := Auth("123")
+	if err != nil {
+		t.Fatalf("Unable to auth: %v", err)
+	}
+
+	second, err := Auth("123")
+	if err != nil {
+		t.Fatalf("Unable to auth: %v", err)
+	}
+
+	if first != second || fake.calls() != 1 {
+		t.Fatalf("Expected the assumed role to be reused, assumed %d", fake.calls())
+	}
+
+	*clock = clock.Add(AuthDuration - AuthExpiryWindow)
+
+	third, err := Auth("123")
+	if err != nil {
+		t.Fatalf("Unable to auth: %v", err)
+	}
+
+	if third == first || fake.calls() != 2 {
+		t.Fatalf("Expected the role to be assumed again once expiring, assumed %d", fake.calls())
+	}
+}
+
+func TestAuthAccountsDontBlock(t *testing.T) {
+	fake, _, restore := withFakeSTS(t)
+	defer restore()
+
+	release := make(chan struct{})
+	fake.blocked = map[string]chan struct{}{"arn:aws:iam::123:role/bakery": release}
+
+	slow := make(chan error)
+	go func() {
+		_, err := Auth("123")
+		slow <- err
+	}()
+
+	done := make(chan error)
+	go func() {
+		_, err := Auth("456")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Unable to auth: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a slow account not to block the others")
+	}
+
+	close(release)
+
+	if err := <-slow; err != nil {
+		t.Fatalf("Unable to auth: %v", err)
+	}
+}
+
+func TestIsNoSuchObject(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{awserr.NewRequestFailure(awserr.New("NoSuchKey", "The specified key does not exist.", nil), 404, "req"), true},
+		{awserr.NewRequestFailure(awserr.New("NotFound", "", nil), 404, "req"), true},
+		{awserr.New("NoSuchVersion", "The specified version does not exist.", nil), true},
+		{awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), 403, "req"), false},
+		{awserr.NewRequestFailure(awserr.New("SlowDown", "Please reduce your request rate.", nil), 503, "req"), false},
+	}
+
+	for _, tc := range testCases {
+		if isNoSuchObject(tc.err) != tc.expected {
+			t.Errorf("Expected %v to be a missing object: %v", tc.err, tc.expected)
+		}
+	}
+}
+
+func TestValidateSessionDuration(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		valid    bool
+	}{
+		{DefaultSessionDuration, true},
+		{MinSessionDuration, true},
+		{12 * time.Hour, true},
+		{MinSessionDuration - time.Second, false},
+		{AuthExpiryWindow, false},
+		{0, false},
+		{-time.Hour, false},
+	}
+
+	for _, tc := range testCases {
+		if err := validateSessionDuration(tc.duration); (err == nil) != tc.valid {
+			t.Errorf("Expected %v to be valid %v, got %v", tc.duration, tc.valid, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hailocab/go-service-layer/config"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	// DefaultSessionDuration is how long build credentials last by default
+	DefaultSessionDuration = time.Hour
+
+	// MinSessionDuration is the shortest session STS will hand out
+	MinSessionDuration = 15 * time.Minute
+)
+
+var (
+	// SessionDuration is how long the credentials handed to builds last
+	SessionDuration = DefaultSessionDuration
+
+	// StaticFallback allows builds to use an account's static credentials
+	// when its role can't be assumed
+	StaticFallback = false
+)
+
+// BuildCredentials returns short lived credentials for a build, assumed
+// from the account's role with sessionName, and when they expire. The
+// static credentials are only used if the role can't be assumed and
+// StaticFallback is set, they don't expire so their expiry is zero
+func BuildCredentials(accountID string, sessionName string) (map[string]string, time.Time, error) {
+	account, err := GetAccount(accountID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	creds, expires, err := account.sessionCredentials(sessionName, SessionDuration)
+	if err == nil {
+		return creds, expires, nil
+	}
+
+	if !StaticFallback {
+		return nil, time.Time{}, err
+	}
+
+	log.Warnf("Falling back to static credentials for account %q: %v", accountID, err)
+
+	creds, err = LoadEncryptedAccountInfo(accountID)
+	return creds, time.Time{}, err
+}
+
+func (a *Account) sessionCredentials(sessionName string, duration time.Duration) (map[string]string, time.Time, error) {
+	// Taken before assuming the role, so the session never outlives it
+	expires := now().Add(duration)
+
+	config, err := a.AssumeRole(sessionName, duration)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("Unable to assume role for account %q: %v", a.ID, err)
+	}
+
+	value, err := config.Credentials.Get()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("Unable to get credentials for account %q: %v", a.ID, err)
+	}
+
+	return map[string]string{
+		"aws_access_key_id":     value.AccessKeyID,
+		"aws_secret_access_key": value.SecretAccessKey,
+		"aws_session_token":     value.SessionToken,
+	}, expires, nil
+}
+
+func loadCredentialsConfig() error {
+	configJSON := config.AtPath(
+		"hailo", "service", "bakery", "sts",
+	).AsJson()
+
+	log.Debugf("STS Config: %v", string(configJSON))
+
+	conf := stsConfig{
+		Duration: DefaultSessionDuration.String(),
+	}
+
+	if err := json.Unmarshal(configJSON, &conf); err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(conf.Duration)
+	if err != nil {
+		return fmt.Errorf("Invalid session duration %q: %v", conf.Duration, err)
+	}
+
+	if err := validateSessionDuration(duration); err != nil {
+		log.Warnf("%v, using %v", err, DefaultSessionDuration)
+		duration = DefaultSessionDuration
+	}
+
+	SessionDuration = duration
+	StaticFallback = conf.StaticFallback
+
+	return nil
+}
+
+// validateSessionDuration checks a session is one STS will hand out, and
+// that it lasts long enough to be renewed before it expires
+func validateSessionDuration(d time.Duration) error {
+	if d < MinSessionDuration {
+		return fmt.Errorf("Session duration %v is shorter than the STS minimum of %v", d, MinSessionDuration)
+	}
+
+	if d <= AuthExpiryWindow {
+		return fmt.Errorf("Session duration %v doesn't outlast the %v renewal window", d, AuthExpiryWindow)
+	}
+
+	return nil
+}
+
+type stsConfig struct {
+	Duration       string `json:"duration"`
+	StaticFallback bool   `json:"staticFallback"`
+}
@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"io"
 	"os"
 	"time"
 
@@ -11,6 +12,11 @@ import (
 	log "github.com/cihub/seelog"
 )
 
+// newSTSClient creates the client roles are assumed with, replaced in tests
+var newSTSClient = func() stscreds.AssumeRoler {
+	return sts.New(session.New(), &aws.Config{Region: aws.String(os.Getenv("EC2_REGION"))})
+}
+
 // Account structure
 type Account struct {
 	ID      string   `json:"id"`
@@ -18,13 +24,50 @@ type Account struct {
 	SNSRole string   `json:"snsRole"`
 }
 
+// S3Object is the body of an object along with its metadata
+type S3Object struct {
+	Body    io.ReadCloser
+	Key     string
+	Version string
+	ETag    string
+}
+
+// S3Part is an uploaded part of a multipart upload
+type S3Part struct {
+	Number int64
+	ETag   string
+}
+
+// S3ObjectInfo describes an object without its body
+type S3ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// HasRegion reports whether region is configured for the account
+func (a *Account) HasRegion(region string) bool {
+	for _, r := range a.Regions {
+		if r == region {
+			return true
+		}
+	}
+
+	return false
+}
+
 // AssumeRole performs an API req to give temporary permissions to a service
 func (a *Account) AssumeRole(sessionName string, duration time.Duration) (*aws.Config, error) {
 	log.Debugf("Trying to assume role '%s' in '%s'", a.SNSRole, os.Getenv("EC2_REGION"))
-	svc := sts.New(session.New(), &aws.Config{Region: aws.String(os.Getenv("EC2_REGION"))})
+	svc := newSTSClient()
+
+	creds := stscreds.NewCredentialsWithClient(svc, a.SNSRole, func(p *stscreds.AssumeRoleProvider) {
+		p.RoleSessionName = sessionName
+		p.Duration = duration
+	})
 
 	return &aws.Config{
-		Credentials: stscreds.NewCredentialsWithClient(svc, a.SNSRole),
+		Credentials: creds,
 		Region:      aws.String(os.Getenv("EC2_REGION")),
 	}, nil
 }
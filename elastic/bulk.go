@@ -0,0 +1,238 @@
+package elastic
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	// DefaultBulkSize is how many documents are sent in a bulk request
+	DefaultBulkSize = 500
+
+	// DefaultFlushInterval is how long documents wait to be sent at most
+	DefaultFlushInterval = 5 * time.Second
+
+	// DefaultBulkRetries is how many more times failed documents are sent
+	DefaultBulkRetries = 3
+
+	// bulkBackoff is the wait before the first retry, doubling after each one
+	bulkBackoff = 500 * time.Millisecond
+)
+
+// indexFunc indexes docs in one request. Errors are returned per document,
+// nil for those indexed, unless the whole request failed
+type indexFunc func(docs []string) ([]error, error)
+
+// bulkItem is the outcome of a document in a bulk request
+type bulkItem struct {
+	Status int
+	Error  interface{}
+}
+
+// bulkErrors returns an error for each of n documents that items don't
+// show as indexed. Documents without an item are failed too
+func bulkErrors(n int, items []bulkItem) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		if i >= len(items) {
+			errs[i] = fmt.Errorf("Unable to index message, no response")
+			continue
+		}
+
+		if s := items[i].Status; s < 200 || s > 299 {
+			errs[i] = fmt.Errorf("Unable to index message, status %d: %v", s, items[i].Error)
+		}
+	}
+
+	return errs
+}
+
+// BulkWriter batches documents into bulk requests. Documents are sent
+// once enough are waiting, or every interval. Failed documents are retried
+// and counted if they still fail, Close reports them
+type BulkWriter struct {
+	sync.Mutex
+
+	index    indexFunc
+	size     int
+	interval time.Duration
+	retries  int
+	backoff  time.Duration
+
+	docs    []string
+	indexed int
+	failed  int
+	err     error
+	started bool
+	closed  bool
+
+	// sending keeps a single bulk request in flight
+	sending sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newBulkWriter(index indexFunc, size int, interval time.Duration, retries int) *BulkWriter {
+	if size <= 0 {
+		size = DefaultBulkSize
+	}
+
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	if retries < 0 {
+		retries = 0
+	}
+
+	return &BulkWriter{
+		index:    index,
+		size:     size,
+		interval: interval,
+		retries:  retries,
+		backoff:  bulkBackoff,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Write queues body to be indexed, sending the batch if it's full.
+// Failures to index are reported by Close rather than here, so callers
+// don't write the same document twice
+func (w *BulkWriter) Write(body string) error {
+	w.Lock()
+	if w.closed {
+		w.Unlock()
+		return fmt.Errorf("Bulk writer is closed")
+	}
+
+	// The interval flush is only needed once there's something to send
+	if !w.started {
+		w.started = true
+		go w.loop()
+	}
+
+	w.docs = append(w.docs, body)
+	full := len(w.docs) >= w.size
+	w.Unlock()
+
+	if full {
+		if err := w.Flush(); err != nil {
+			log.Errorf("%v", err)
+		}
+	}
+
+	return nil
+}
+
+// Flush sends every queued document
+func (w *BulkWriter) Flush() error {
+	w.sending.Lock()
+	defer w.sending.Unlock()
+
+	w.Lock()
+	docs := w.docs
+	w.docs = nil
+	w.Unlock()
+
+	return w.send(docs)
+}
+
+// Close sends the documents still queued and stops the writer. It errors
+// if any document couldn't be indexed
+func (w *BulkWriter) Close() error {
+	w.Lock()
+	if w.closed {
+		w.Unlock()
+		return fmt.Errorf("Bulk writer is already closed")
+	}
+
+	w.closed = true
+	started := w.started
+	w.Unlock()
+
+	if started {
+		close(w.stop)
+		<-w.done
+	}
+
+	w.Flush()
+
+	w.Lock()
+	defer w.Unlock()
+
+	if w.failed > 0 {
+		return fmt.Errorf("Unable to index %d of %d messages: %v", w.failed, w.failed+w.indexed, w.err)
+	}
+
+	return nil
+}
+
+func (w *BulkWriter) loop() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Flush(); err != nil {
+				log.Errorf("%v", err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// send indexes docs, retrying the ones that failed with backoff
+func (w *BulkWriter) send(docs []string) error {
+	backoff := w.backoff
+
+	for attempt := 0; len(docs) > 0; attempt++ {
+		var (
+			retry   []string
+			lastErr error
+		)
+
+		errs, err := w.index(docs)
+		if err != nil {
+			retry, lastErr = docs, err
+		} else {
+			for i, e := range errs {
+				if e != nil {
+					retry, lastErr = append(retry, docs[i]), e
+				}
+			}
+		}
+
+		w.Lock()
+		w.indexed += len(docs) - len(retry)
+		if len(retry) > 0 && attempt >= w.retries {
+			w.failed += len(retry)
+			w.err = lastErr
+		}
+		w.Unlock()
+
+		if len(retry) == 0 {
+			return nil
+		}
+
+		if attempt >= w.retries {
+			return fmt.Errorf("Unable to index %d messages: %v", len(retry), lastErr)
+		}
+
+		log.Debugf("Retrying %d of %d messages: %v", len(retry), len(docs), lastErr)
+
+		time.Sleep(backoff)
+		backoff *= 2
+		docs = retry
+	}
+
+	return nil
+}
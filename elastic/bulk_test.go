@@ -0,0 +1,156 @@
+package elastic
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIndex records the batches sent, failing documents listed in fail
+// until they've been sent attempts times
+type fakeIndex struct {
+	sync.Mutex
+	batches  [][]string
+	fail     map[string]int
+	requests int
+}
+
+func (f *fakeIndex) index(docs []string) ([]error, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.batches = append(f.batches, docs)
+	if f.requests > 0 {
+		f.requests--
+		return nil, fmt.Errorf("Unavailable")
+	}
+
+	errs := make([]error, len(docs))
+	for i, d := range docs {
+		if f.fail[d] > 0 {
+			f.fail[d]--
+			errs[i] = fmt.Errorf("Rejected %s", d)
+		}
+	}
+
+	return errs, nil
+}
+
+func (f *fakeIndex) sent() int {
+	f.Lock()
+	defer f.Unlock()
+
+	return len(f.batches)
+}
+
+func newTestWriter(f *fakeIndex, size int, interval time.Duration, retries int) *BulkWriter {
+	w := newBulkWriter(f.index, size, interval, retries)
+	w.backoff = time.Millisecond
+
+	return w
+}
+
+func TestBulkWriterSize(t *testing.T) {
+	f := &fakeIndex{}
+	w := newTestWriter(f, 2, time.Hour, 0)
+
+	for _, d := range []string{"a", "b", "c"} {
+		if err := w.Write(d); err != nil {
+			t.Fatalf("Unable to write: %v", err)
+		}
+	}
+
+	if f.sent() != 1 || len(f.batches[0]) != 2 {
+		t.Fatalf("Expected a full batch to be sent, got %v", f.batches)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unable to close: %v", err)
+	}
+
+	if f.sent() != 2 || len(f.batches[1]) != 1 || f.batches[1][0] != "c" {
+		t.Fatalf("Expected close to send the rest, got %v", f.batches)
+	}
+
+	if err := w.Write("d"); err == nil {
+		t.Error("Expected writing after close to error")
+	}
+}
+
+func TestBulkWriterInterval(t *testing.T) {
+	f := &fakeIndex{}
+	w := newTestWriter(f, 100, 10*time.Millisecond, 0)
+	defer w.Close()
+
+	w.Write("a")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for f.sent() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the batch to be sent on the interval")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBulkWriterRetries(t *testing.T) {
+	f := &fakeIndex{
+		fail:     map[string]int{"b": 1, "c": 10},
+		requests: 1,
+	}
+
+	w := newTestWriter(f, 100, time.Hour, 2)
+	for _, d := range []string{"a", "b", "c"} {
+		w.Write(d)
+	}
+
+	err := w.Close()
+	if err == nil {
+		t.Fatal("Expected close to report the failed message")
+	}
+
+	if err.Error() != "Unable to index 1 of 3 messages: Rejected c" {
+		t.Errorf("Unexpected error %q", err)
+	}
+
+	// The failed request is sent again, then only the failed documents
+	expected := []int{3, 3, 2}
+	if len(f.batches) != len(expected) {
+		t.Fatalf("Expected %d requests, got %v", len(expected), f.batches)
+	}
+
+	for i, n := range expected {
+		if len(f.batches[i]) != n {
+			t.Errorf("Expected request %d to have %d documents, got %v", i, n, f.batches[i])
+		}
+	}
+}
+
+func TestBulkWriterCloseUnused(t *testing.T) {
+	f := &fakeIndex{}
+	w := newTestWriter(f, 100, time.Hour, 0)
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unable to close: %v", err)
+	}
+
+	if f.sent() != 0 {
+		t.Errorf("Expected nothing to be sent, got %v", f.batches)
+	}
+}
+
+func TestBulkErrors(t *testing.T) {
+	errs := bulkErrors(4, []bulkItem{
+		{Status: 201},
+		{Status: 429, Error: "es_rejected_execution_exception"},
+		{Status: 200},
+	})
+
+	for i, failed := range []bool{false, true, false, true} {
+		if (errs[i] != nil) != failed {
+			t.Errorf("Expected document %d failed to be %v, got %v", i, failed, errs[i])
+		}
+	}
+}
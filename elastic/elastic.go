@@ -3,11 +3,12 @@ package elastic
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/hailocab/go-service-layer/config"
 
 	log "github.com/cihub/seelog"
-	"github.com/nu7hatch/gouuid"
 	"gopkg.in/olivere/elastic.v2"
 )
 
@@ -21,6 +22,11 @@ type Elastic struct {
 	Host  string
 	Index string
 
+	// Bulk writer settings, the defaults are used if unset
+	BulkSize      int
+	FlushInterval time.Duration
+	BulkRetries   int
+
 	client *elastic.Client
 }
 
@@ -41,6 +47,10 @@ func NewWithDefaults() (*Elastic, error) {
 		Host:  Config.Host,
 		Index: Config.Index,
 
+		BulkSize:      Config.BulkSize,
+		FlushInterval: Config.flushInterval,
+		BulkRetries:   Config.BulkRetries,
+
 		client: client,
 	}
 
@@ -82,17 +92,81 @@ func (e *Elastic) IndexExists(name string) (bool, error) {
 	return ok, nil
 }
 
-func (e *Elastic) Write(body string) error {
-	id, err := uuid.NewV4()
+// NewBulkWriter creates a writer indexing messages in bulk
+func (e *Elastic) NewBulkWriter() *BulkWriter {
+	return newBulkWriter(e.bulkIndex, e.BulkSize, e.FlushInterval, e.BulkRetries)
+}
+
+// bulkIndex indexes docs as messages in one request. Documents are given
+// IDs by elastic search
+func (e *Elastic) bulkIndex(docs []string) ([]error, error) {
+	bulk := e.client.Bulk()
+	for _, d := range docs {
+		bulk.Add(elastic.NewBulkIndexRequest().Index(e.Index).Type("message").Doc(json.RawMessage(d)))
+	}
+
+	res, err := bulk.Do()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Unable to index messages: %v", err)
 	}
 
-	if _, err := e.client.Index().Index(e.Index).Type("message").Id(id.String()).BodyString(body).Do(); err != nil {
-		return err
+	// Items are in the order of the requests
+	items := make([]bulkItem, len(res.Items))
+	for i, item := range res.Items {
+		for _, r := range item {
+			items[i] = bulkItem{Status: r.Status, Error: r.Error}
+		}
 	}
 
-	return nil
+	return bulkErrors(len(docs), items), nil
+}
+
+// Messages returns the documents written for a build, in the order they
+// were written. Only documents of the given builder and types are
+// returned if set, and those sequenced after since
+func (e *Elastic) Messages(id string, builder string, types []string, since uint64, offset int, limit int) ([]*json.RawMessage, error) {
+	q := elastic.NewBoolQuery()
+	q = q.Must(elastic.NewMatchPhraseQuery("ID", id))
+
+	if len(builder) > 0 {
+		q = q.Must(elastic.NewMatchPhraseQuery("Builder", builder))
+	}
+
+	if since > 0 {
+		q = q.Must(elastic.NewRangeQuery("Sequence").Gt(since))
+	}
+
+	if len(types) > 0 {
+		values := make([]interface{}, len(types))
+		for i, t := range types {
+			values[i] = strings.ToLower(t)
+		}
+
+		q = q.Must(elastic.NewTermsQuery("Type", values...))
+	}
+
+	res, err := e.client.Search(e.Index).
+		Type("message").
+		Query(q).
+		Sort("Sequence", true).
+		From(offset).
+		Size(limit).
+		Do()
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to search messages: %v", err)
+	}
+
+	if res.Hits == nil {
+		return nil, nil
+	}
+
+	docs := make([]*json.RawMessage, len(res.Hits.Hits))
+	for i, h := range res.Hits.Hits {
+		docs[i] = h.Source
+	}
+
+	return docs, nil
 }
 
 // Init loads config and sets up elastic search
@@ -112,15 +186,32 @@ func loadConfig() (*esConfig, error) {
 
 	log.Debugf("Elastic Config: %v", string(configJSON))
 
-	var conf esConfig
+	conf := esConfig{
+		BulkSize:      DefaultBulkSize,
+		FlushInterval: DefaultFlushInterval.String(),
+		BulkRetries:   DefaultBulkRetries,
+	}
+
 	if err := json.Unmarshal(configJSON, &conf); err != nil {
 		return nil, err
 	}
 
+	interval, err := time.ParseDuration(conf.FlushInterval)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid flush interval %q: %v", conf.FlushInterval, err)
+	}
+
+	conf.flushInterval = interval
+
 	return &conf, nil
 }
 
 type esConfig struct {
-	Index string `json:"index"`
-	Host  string `json:"host"`
+	Index         string `json:"index"`
+	Host          string `json:"host"`
+	BulkSize      int    `json:"bulkSize"`
+	FlushInterval string `json:"flushInterval"`
+	BulkRetries   int    `json:"bulkRetries"`
+
+	flushInterval time.Duration
 }
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"time"
+
+	"github.com/hailocab/bakery-service/packer/ui"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	// TypeMessage is published for every message a build writes
+	TypeMessage = "build.message"
+
+	// TypeStarted is published when a build leaves the queue
+	TypeStarted = "build.started"
+
+	// TypeArtifact is published as each artifact is built
+	TypeArtifact = "build.artifact"
+
+	// TypeBuilderFinished is published as each builder of a build finishes
+	TypeBuilderFinished = "build.builder.finished"
+
+	// TypeSucceeded is published when every builder of a build succeeded
+	TypeSucceeded = "build.succeeded"
+
+	// TypeFailed is published when a build failed
+	TypeFailed = "build.failed"
+
+	// TypeCancelled is published when a build was cancelled
+	TypeCancelled = "build.cancelled"
+)
+
+var (
+	// Default publisher, events aren't published if it's nil
+	Default Publisher
+)
+
+// Publisher sends events to subscribers
+type Publisher interface {
+	Publish(e *Event) error
+}
+
+// Event describes something that happened to a build
+type Event struct {
+	Type    string    `json:"type"`
+	BuildID string    `json:"buildId"`
+	Date    time.Time `json:"date"`
+
+	// Builder is set for builder events
+	Builder string `json:"builder,omitempty"`
+
+	// MessageType and Message are set for message events
+	MessageType string `json:"messageType,omitempty"`
+	Message     string `json:"message,omitempty"`
+
+	// Artifacts holds the IDs of the artifacts built
+	Artifacts []string `json:"artifacts,omitempty"`
+
+	// Errors are keyed by builder name
+	Errors map[string]string `json:"errors,omitempty"`
+
+	// LogURL and LogErrors are set on the final event of a build
+	LogURL    string   `json:"logURL,omitempty"`
+	LogErrors []string `json:"logErrors,omitempty"`
+}
+
+// New creates an event of type t for a build
+func New(t string, id string) *Event {
+	return &Event{
+		Type:    t,
+		BuildID: id,
+		Date:    time.Now(),
+	}
+}
+
+// Publish sends e with the default publisher, errors are logged
+func Publish(e *Event) {
+	if Default == nil {
+		return
+	}
+
+	if err := Default.Publish(e); err != nil {
+		log.Errorf("[%s] Unable to publish %s event: %v", e.BuildID, e.Type, err)
+	}
+}
+
+// Caller publishes every message of a build
+type Caller struct {
+	ID string
+}
+
+// NewCaller creates a caller for build id
+func NewCaller(id string) *Caller {
+	return &Caller{
+		ID: id,
+	}
+}
+
+// Call publishes msg as a message event
+func (c *Caller) Call(msg *ui.Message) error {
+	if Default == nil {
+		return nil
+	}
+
+	e := New(TypeMessage, c.ID)
+	e.Builder = msg.Builder
+	e.MessageType = msg.Type.String()
+	e.Message = msg.Message
+
+	return Default.Publish(e)
+}
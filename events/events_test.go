@@ -0,0 +1,49 @@
+package events
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hailocab/bakery-service/packer/ui"
+)
+
+type fakePublisher struct {
+	sync.Mutex
+	events []*Event
+}
+
+func (p *fakePublisher) Publish(e *Event) error {
+	p.Lock()
+	defer p.Unlock()
+
+	p.events = append(p.events, e)
+
+	return nil
+}
+
+func TestCaller(t *testing.T) {
+	p := &fakePublisher{}
+	Default = p
+	defer func() { Default = nil }()
+
+	c := NewCaller("abc")
+	if err := c.Call(&ui.Message{Message: "Starting"}); err != nil {
+		t.Fatalf("Unable to call: %v", err)
+	}
+
+	if len(p.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(p.events))
+	}
+
+	e := p.events[0]
+	if e.Type != TypeMessage || e.BuildID != "abc" || e.Message != "Starting" || e.Date.IsZero() {
+		t.Errorf("Unexpected event %#v", e)
+	}
+}
+
+func TestPublishWithoutPublisher(t *testing.T) {
+	Default = nil
+
+	// Shouldn't panic
+	Publish(New(TypeStarted, "abc"))
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hailocab/bakery-service/aws"
+
+	"github.com/hailocab/go-service-layer/config"
+
+	log "github.com/cihub/seelog"
+)
+
+// SNSPublisher publishes events as JSON to an SNS topic, using the role
+// of an account
+type SNSPublisher struct {
+	Account string
+	Topic   string
+}
+
+// NewSNSPublisher creates a publisher for topic
+func NewSNSPublisher(account string, topic string) *SNSPublisher {
+	return &SNSPublisher{
+		Account: account,
+		Topic:   topic,
+	}
+}
+
+// Publish sends e to the topic
+func (p *SNSPublisher) Publish(e *Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("Unable to encode event: %v", err)
+	}
+
+	return aws.PublishSNS(p.Account, p.Topic, string(data))
+}
+
+// Init loads config, events are only published if a topic is set
+func Init() {
+	conf, err := loadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(conf.Topic) == 0 {
+		log.Info("No events topic configured, events won't be published")
+		return
+	}
+
+	Default = NewSNSPublisher(conf.Account, conf.Topic)
+}
+
+func loadConfig() (*eventsConfig, error) {
+	configJSON := config.AtPath(
+		"hailo", "service", "bakery", "events",
+	).AsJson()
+
+	log.Debugf("Events Config: %v", string(configJSON))
+
+	conf := eventsConfig{
+		Account: aws.DefaultAccount,
+	}
+
+	if err := json.Unmarshal(configJSON, &conf); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+type eventsConfig struct {
+	Account string `json:"account"`
+	Topic   string `json:"topic"`
+}
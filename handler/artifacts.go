@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"fmt"
+
+	protoArtifacts "github.com/hailocab/bakery-service/proto/artifacts"
+
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/registry"
+
+	"github.com/hailocab/go-platform-layer/errors"
+	"github.com/hailocab/go-platform-layer/server"
+
+	"github.com/hailocab/protobuf/proto"
+	mpacker "github.com/mitchellh/packer/packer"
+)
+
+const (
+	// ArtifactsEndpoint name of endpoint
+	ArtifactsEndpoint = "com.hailocab.infrastructure.bakery.artifacts"
+)
+
+// Artifacts endpoint
+func Artifacts(req *server.Request) (proto.Message, errors.Error) {
+	request := req.Data().(*protoArtifacts.Request)
+
+	b, err := registry.Default.Get(request.GetId())
+	if err == registry.ErrNotFound {
+		return nil, errors.NotFound(ArtifactsEndpoint,
+			fmt.Sprintf("Unknown build %q", request.GetId()),
+		)
+	}
+
+	if err != nil {
+		return nil, errors.InternalServerError(ArtifactsEndpoint, err)
+	}
+
+	rsp := &protoArtifacts.Response{
+		Id:    proto.String(b.ID),
+		State: proto.String(b.State.String()),
+	}
+
+	for _, a := range b.Artifacts {
+		artifact := &protoArtifacts.Artifact{
+			Builder:     proto.String(a.Builder),
+			BuilderId:   proto.String(a.BuilderID),
+			Id:          proto.String(a.ID),
+			Files:       a.Files,
+			Description: proto.String(a.Description),
+		}
+
+		if len(a.AMI) > 0 {
+			artifact.Region = proto.String(a.Region)
+			artifact.Ami = proto.String(a.AMI)
+		}
+
+		rsp.Artifacts = append(rsp.Artifacts, artifact)
+	}
+
+	return rsp, nil
+}
+
+// newArtifacts converts packer artifacts for the registry, amazon
+// artifacts are split into one per region
+func newArtifacts(artifacts map[string][]mpacker.Artifact) []*registry.Artifact {
+	var _artifacts []*registry.Artifact
+	for n, as := range artifacts {
+		for _, a := range as {
+			_artifacts = append(_artifacts, newArtifact(&registry.Artifact{
+				Builder:     n,
+				BuilderID:   a.BuilderId(),
+				ID:          a.Id(),
+				Files:       a.Files(),
+				Description: a.String(),
+			})...)
+		}
+	}
+
+	return _artifacts
+}
+
+// newArtifact splits an artifact holding AMIs into one per region
+func newArtifact(artifact *registry.Artifact) []*registry.Artifact {
+	amis, ok := packer.ParseAMIs(artifact.ID)
+	if !ok {
+		return []*registry.Artifact{artifact}
+	}
+
+	var _artifacts []*registry.Artifact
+	for _, ami := range amis {
+		_artifact := *artifact
+		_artifact.Region = ami.Region
+		_artifact.AMI = ami.ID
+		_artifacts = append(_artifacts, &_artifact)
+	}
+
+	return _artifacts
+}
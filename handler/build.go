@@ -4,13 +4,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	protoBuild "github.com/hailocab/bakery-service/proto/build"
 
 	"github.com/hailocab/bakery-service/aws"
 	"github.com/hailocab/bakery-service/elastic"
+	"github.com/hailocab/bakery-service/events"
+	"github.com/hailocab/bakery-service/logs"
 	"github.com/hailocab/bakery-service/packer"
 	"github.com/hailocab/bakery-service/packer/ui"
+	"github.com/hailocab/bakery-service/queue"
+	"github.com/hailocab/bakery-service/registry"
+	"github.com/hailocab/bakery-service/storage"
+	"github.com/hailocab/bakery-service/workspace"
 
 	"github.com/hailocab/go-platform-layer/errors"
 	"github.com/hailocab/go-platform-layer/server"
@@ -24,14 +31,33 @@ const (
 	// BuildEndpoint name of endpoint
 	BuildEndpoint = "com.hailocab.infrastructure.bakery.build"
 
-	// BucketName S3 bucket to find templates
-	BucketName = "hailo-bakery"
+	// BucketTemplatePath storage path where templates are stored
+	BucketTemplatePath = "templates"
+)
+
+var (
+	// lossyCaller drops messages rather than wait on a slow caller
+	lossyCaller = ui.CallerOptions{
+		BufferSize: 1000,
+		Policy:     ui.PolicyDrop,
+		Retries:    3,
+		Backoff:    100 * time.Millisecond,
+	}
 
-	// BucketLogPath path to store logs
-	BucketLogPath = "logs"
+	// indexCaller drops messages rather than wait on elastic search. Its
+	// calls only queue messages, the bulk writer retries failed ones
+	indexCaller = ui.CallerOptions{
+		BufferSize: 1000,
+		Policy:     ui.PolicyDrop,
+		Retries:    0,
+	}
 
-	// BucketTemplatePath path where templates are stored
-	BucketTemplatePath = "templates"
+	// archiveCaller waits on the log archive, whose write errors stick so
+	// retrying is pointless
+	archiveCaller = ui.CallerOptions{
+		BufferSize: 1000,
+		Policy:     ui.PolicyBlock,
+	}
 )
 
 // Build endpoint
@@ -50,36 +76,55 @@ func Build(req *server.Request) (proto.Message, errors.Error) {
 	template := request.GetTemplate()
 	log.Infof("Requested Template: %v", template)
 
-	dir, err := packer.TemporaryDir()
+	t, err := resolveTarget(request.GetAccountId(), request.GetRegions())
+	if err != nil {
+		return nil, errors.BadRequest(BuildEndpoint, err.Error())
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, errors.InternalServerError(BuildEndpoint,
+			fmt.Sprintf("Unable to create ID: %v", err),
+		)
+	}
+
+	dir, err := workspace.Default.Create(id.String())
 	if err != nil {
 		return nil, errors.InternalServerError(BuildEndpoint, err)
 	}
 
-	rc, err := aws.GetS3Object(BucketName, fmt.Sprintf("%s/%s.zip", BucketTemplatePath, template))
+	// Once queued the workspace is released when the build ends
+	queued := false
+	defer func() {
+		if queued {
+			return
+		}
+
+		if err := workspace.Default.Release(id.String(), false); err != nil {
+			log.Errorf("[%s] Unable to release workspace: %v", id.String(), err)
+		}
+	}()
+
+	obj, err := fetchTemplate(template, request.GetFormat(), request.GetVersion())
 	if err != nil {
 		return nil, errors.BadRequest(BuildEndpoint,
 			fmt.Sprintf("Unable to get object: %v", err),
 		)
 	}
 
-	defer rc.Close()
-
-	if err := packer.UnzipReader(rc, dir); err != nil {
+	source, err := extractTemplate(obj, dir)
+	if err != nil {
 		return nil, errors.InternalServerError(BuildEndpoint, err)
 	}
 
+	log.Infof("[%s] Baking in account %s, regions %v", id.String(), t.Account.ID, t.Regions)
+	log.Infof("[%s] Using template %s (version %q, checksum %s)", id.String(), source.Key, source.Version, source.Checksum)
+
 	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("%s.json", template)))
 	if err != nil {
 		return nil, errors.InternalServerError(BuildEndpoint, err)
 	}
 
-	id, err := uuid.NewV4()
-	if err != nil {
-		return nil, errors.InternalServerError(BuildEndpoint,
-			fmt.Sprintf("Unable to create ID: %v", err),
-		)
-	}
-
 	e, err := elastic.NewWithDefaults()
 	if err != nil {
 		return nil, errors.InternalServerError(BuildEndpoint,
@@ -87,9 +132,17 @@ func Build(req *server.Request) (proto.Message, errors.Error) {
 		)
 	}
 
-	ui := ui.New(
-		ui.AddCaller("echo", &ui.EchoCaller{}),
-		ui.AddCaller("elastic", ui.NewElasticCaller(id.String(), e)),
+	logArchive := ui.NewS3Caller(storage.Default, logs.Key(id.String()))
+	logIndex := ui.NewElasticCaller(e)
+
+	// The log archive and artifact tracking can't miss messages, the
+	// others mustn't hold the build up
+	ui := ui.New(id.String(),
+		ui.AddCallerWithOptions("echo", &ui.EchoCaller{}, lossyCaller),
+		ui.AddCallerWithOptions("elastic", logIndex, indexCaller),
+		ui.AddCallerWithOptions("s3", logArchive, archiveCaller),
+		ui.AddCallerWithOptions("events", events.NewCaller(id.String()), lossyCaller),
+		ui.AddCaller("tracker", newTracker(id.String())),
 	)
 
 	p, err = packer.New(f, ui)
@@ -99,20 +152,57 @@ func Build(req *server.Request) (proto.Message, errors.Error) {
 		)
 	}
 
-	creds, err := aws.LoadEncryptedAccountInfo()
+	creds, _, err := aws.BuildCredentials(t.Account.ID, sessionName(id.String()))
 	if err != nil {
 		return nil, errors.InternalServerError(BuildEndpoint, err)
 	}
 
-	vars := packer.ExtractVariables(p.Template.Variables, map[string]string{
-		"cwd":                   dir,
-		"aws_access_key_id":     creds["aws_access_key_id"],
-		"aws_secret_access_key": creds["aws_secret_access_key"],
+	for k := range reqVars {
+		if _, ok := p.Template.Variables[k]; !ok {
+			return nil, errors.BadRequest(BuildEndpoint,
+				fmt.Sprintf("Variable %q is not defined in template %q", k, template),
+			)
+		}
+	}
+
+	// Service injected variables take precedence over the request,
+	// which in turn overrides the template defaults
+	vars := packer.ExtractVariables(p.Template.Variables, serviceVariables(dir, t, creds), reqVars)
+
+	if ok, err := packer.CheckVariables(vars); !ok {
+		return nil, errors.BadRequest(BuildEndpoint, err.Error())
+	}
+
+	if _, err := registry.Default.Create(id.String(), template, source, t.Account.ID, t.Regions); err != nil {
+		return nil, errors.InternalServerError(BuildEndpoint,
+			fmt.Sprintf("Unable to register build: %v", err),
+		)
+	}
+
+	pos, err := queue.Default.Push(&queue.Job{
+		ID:       id.String(),
+		Priority: int(request.GetPriority()),
+		Run: func() {
+			run(id.String(), t.Account.ID, dir, p, vars, ui, logArchive, logIndex)
+		},
 	})
 
-	go p.Build(vars)
+	if err != nil {
+		if err := registry.Default.Delete(id.String()); err != nil {
+			log.Errorf("[%s] Unable to remove rejected build: %v", id.String(), err)
+		}
+
+		if err == queue.ErrQueueFull {
+			return nil, errors.BadRequest(BuildEndpoint+".queuefull", err.Error())
+		}
+
+		return nil, errors.InternalServerError(BuildEndpoint, err)
+	}
+
+	queued = true
 
 	return &protoBuild.Response{
-		Id: proto.String(id.String()),
+		Id:       proto.String(id.String()),
+		Position: proto.Int32(int32(pos)),
 	}, nil
 }
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"fmt"
+
+	protoCancel "github.com/hailocab/bakery-service/proto/cancel"
+
+	"github.com/hailocab/bakery-service/queue"
+	"github.com/hailocab/bakery-service/registry"
+	"github.com/hailocab/bakery-service/workspace"
+
+	"github.com/hailocab/go-platform-layer/errors"
+	"github.com/hailocab/go-platform-layer/server"
+
+	log "github.com/cihub/seelog"
+	"github.com/hailocab/protobuf/proto"
+)
+
+const (
+	// CancelEndpoint name of endpoint
+	CancelEndpoint = "com.hailocab.infrastructure.bakery.cancel"
+)
+
+// Cancel endpoint
+func Cancel(req *server.Request) (proto.Message, errors.Error) {
+	request := req.Data().(*protoCancel.Request)
+
+	removed := queue.Default.Remove(request.GetId())
+	if removed {
+		log.Infof("[%s] Removed build from the queue", request.GetId())
+
+		if err := workspace.Default.Release(request.GetId(), false); err != nil {
+			log.Errorf("[%s] Unable to release workspace: %v", request.GetId(), err)
+		}
+	}
+
+	b, err := registry.Default.Cancel(request.GetId())
+	switch err {
+	case nil:
+	case registry.ErrNotFound:
+		return nil, errors.NotFound(CancelEndpoint,
+			fmt.Sprintf("Unknown build %q", request.GetId()),
+		)
+	case registry.ErrFinished:
+		return nil, errors.BadRequest(CancelEndpoint,
+			fmt.Sprintf("Build %q has already finished", request.GetId()),
+		)
+	default:
+		return nil, errors.InternalServerError(CancelEndpoint, err)
+	}
+
+	// Builds that never left the queue won't publish their own outcome
+	if removed {
+		buildFinished(b.ID)
+	}
+
+	return &protoCancel.Response{
+		Id:    proto.String(b.ID),
+		State: proto.String(b.State.String()),
+	}, nil
+}
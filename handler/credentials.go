@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hailocab/bakery-service/aws"
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/registry"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	// credentialsFile is where a build's credentials are kept in its
+	// workspace
+	credentialsFile = ".aws-credentials"
+
+	// credentialsRetry is how often assuming the role is retried when
+	// credentials couldn't be renewed
+	credentialsRetry = time.Minute
+)
+
+var (
+	// afterFunc runs f once d has passed, returning a func that stops it.
+	// Replaced in tests
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		return time.AfterFunc(d, f).Stop
+	}
+
+	// now and buildCredentials are replaced in tests
+	now              = time.Now
+	buildCredentials = aws.BuildCredentials
+)
+
+// credentials keeps the credentials of a running build fresh. They're
+// written to a shared credentials file, which the build's plugins are
+// pointed at by Env, and the role is assumed again before they expire.
+// Templates using the file rather than the credential variables keep
+// working for as long as the build runs
+type credentials struct {
+	id        string
+	accountID string
+	path      string
+	cancel    func()
+
+	lock    sync.Mutex
+	stop    func() bool
+	stopped bool
+}
+
+// startCredentials assumes the account's role for a build, setting the
+// credential variables in vars and writing them to the build's workspace.
+// cancel is called if they can't be renewed before they expire
+func startCredentials(id string, accountID string, dir string, vars map[string]*packer.Variable, cancel func()) (*credentials, error) {
+	creds, expires, err := buildCredentials(accountID, sessionName(id))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &credentials{
+		id:        id,
+		accountID: accountID,
+		path:      filepath.Join(dir, credentialsFile),
+		cancel:    cancel,
+	}
+
+	if err := c.write(creds); err != nil {
+		return nil, err
+	}
+
+	for k, v := range creds {
+		if _v, ok := vars[k]; ok {
+			_v.Value = v
+		}
+	}
+
+	c.schedule(expires)
+
+	return c, nil
+}
+
+// Env points the AWS SDK at the build's credentials file
+func (c *credentials) Env() []string {
+	return []string{"AWS_SHARED_CREDENTIALS_FILE=" + c.path}
+}
+
+// Stop stops renewing the credentials and removes them from the workspace
+func (c *credentials) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.stopped = true
+	if c.stop != nil {
+		c.stop()
+	}
+
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		log.Errorf("[%s] Unable to remove credentials: %v", c.id, err)
+	}
+}
+
+// schedule renews credentials expiring at expires ahead of their expiry.
+// Static credentials don't expire, so aren't renewed
+func (c *credentials) schedule(expires time.Time) {
+	if expires.IsZero() {
+		return
+	}
+
+	c.after(expires.Sub(now())-aws.AuthExpiryWindow, func() {
+		c.renew(expires)
+	})
+}
+
+// renew assumes the role again, retrying until the current credentials
+// expire. Only then is the build failed
+func (c *credentials) renew(expires time.Time) {
+	creds, next, err := buildCredentials(c.accountID, sessionName(c.id))
+	if err == nil {
+		err = c.write(creds)
+	}
+
+	if err == nil {
+		log.Infof("[%s] Renewed credentials", c.id)
+		c.schedule(next)
+		return
+	}
+
+	remaining := expires.Sub(now())
+	log.Errorf("[%s] Unable to renew credentials expiring in %v: %v", c.id, remaining, err)
+
+	if remaining > credentialsRetry {
+		c.after(credentialsRetry, func() {
+			c.renew(expires)
+		})
+
+		return
+	}
+
+	c.after(remaining, func() {
+		c.expire(err)
+	})
+}
+
+// expire fails the build, whose credentials have expired
+func (c *credentials) expire(renewErr error) {
+	err := fmt.Errorf("Build credentials expired and couldn't be renewed: %v", renewErr)
+	log.Errorf("[%s] %v", c.id, err)
+
+	recordErr := registry.Default.Update(c.id, func(b *registry.Build) {
+		b.Errors["bakery"] = err.Error()
+	})
+
+	if recordErr != nil {
+		log.Errorf("[%s] Unable to record build failure: %v", c.id, recordErr)
+	}
+
+	c.cancel()
+}
+
+// after runs f once d has passed, unless the credentials were stopped
+func (c *credentials) after(d time.Duration, f func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		return
+	}
+
+	c.stop = afterFunc(d, f)
+}
+
+// write replaces the credentials file, so it's never read half written
+func (c *credentials) write(creds map[string]string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stopped {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "[default]")
+
+	for _, k := range []string{"aws_access_key_id", "aws_secret_access_key", "aws_session_token"} {
+		if len(creds[k]) > 0 {
+			fmt.Fprintf(&buf, "%s = %s\n", k, creds[k])
+		}
+	}
+
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("Unable to write credentials: %v", err)
+	}
+
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("Unable to write credentials: %v", err)
+	}
+
+	return nil
+}
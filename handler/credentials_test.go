@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hailocab/bakery-service/aws"
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/registry"
+)
+
+// fakeClock holds the func scheduled with afterFunc until it's fired
+type fakeClock struct {
+	now     time.Time
+	after   time.Duration
+	f       func()
+	stopped bool
+}
+
+func (c *fakeClock) afterFunc(d time.Duration, f func()) func() bool {
+	c.after, c.f = d, f
+
+	return func() bool {
+		c.stopped = true
+		return true
+	}
+}
+
+// fire moves the clock on to the scheduled func and runs it
+func (c *fakeClock) fire() {
+	f := c.f
+	c.f = nil
+	c.now = c.now.Add(c.after)
+
+	f()
+}
+
+// withFakeClock replaces afterFunc and now with c, returning a func
+// restoring them
+func withFakeClock(c *fakeClock) func() {
+	origAfter, origNow := afterFunc, now
+	afterFunc = c.afterFunc
+	now = func() time.Time { return c.now }
+
+	return func() { afterFunc, now = origAfter, origNow }
+}
+
+// session is a result of assuming a role. Sessions with no duration are
+// static credentials
+type session struct {
+	token    string
+	duration time.Duration
+	err      error
+}
+
+// withSessions hands out sessions in turn as roles are assumed, returning
+// a func restoring buildCredentials
+func withSessions(c *fakeClock, sessions ...session) func() {
+	orig := buildCredentials
+	buildCredentials = func(accountID string, sessionName string) (map[string]string, time.Time, error) {
+		if len(sessions) == 0 {
+			return nil, time.Time{}, fmt.Errorf("No more sessions")
+		}
+
+		s := sessions[0]
+		sessions = sessions[1:]
+		if s.err != nil {
+			return nil, time.Time{}, s.err
+		}
+
+		var expires time.Time
+		if s.duration > 0 {
+			expires = c.now.Add(s.duration)
+		}
+
+		return map[string]string{
+			"aws_access_key_id":     "AKID",
+			"aws_secret_access_key": "SECRET",
+			"aws_session_token":     s.token,
+		}, expires, nil
+	}
+
+	return func() { buildCredentials = orig }
+}
+
+// withRegistry makes an in memory registry the default, returning a func
+// restoring the previous one
+func withRegistry(t *testing.T) func() {
+	r, err := registry.New("")
+	if err != nil {
+		t.Fatalf("Unable to create registry: %v", err)
+	}
+
+	orig := registry.Default
+	registry.Default = r
+
+	return func() { registry.Default = orig }
+}
+
+// withDir creates a temporary directory, returning it and a func removing it
+func withDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("Unable to create directory: %v", err)
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func readCredentials(t *testing.T, dir string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dir, credentialsFile))
+	if err != nil {
+		t.Fatalf("Unable to read credentials: %v", err)
+	}
+
+	return string(b)
+}
+
+func TestCredentialsRenewed(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	defer withFakeClock(clock)()
+	defer withSessions(clock, session{token: "one", duration: time.Hour}, session{token: "two", duration: time.Hour})()
+	dir, cleanup := withDir(t)
+	defer cleanup()
+
+	vars := map[string]*packer.Variable{"aws_session_token": {}}
+	creds, err := startCredentials("abc", "123", dir, vars, func() {
+		t.Error("Expected the build not to be cancelled")
+	})
+
+	if err != nil {
+		t.Fatalf("Unable to start credentials: %v", err)
+	}
+
+	if vars["aws_session_token"].Value != "one" || !strings.Contains(readCredentials(t, dir), "aws_session_token = one") {
+		t.Fatalf("Expected the build to start with the first session")
+	}
+
+	if env := creds.Env(); len(env) != 1 || env[0] != "AWS_SHARED_CREDENTIALS_FILE="+filepath.Join(dir, credentialsFile) {
+		t.Errorf("Expected the plugins to be pointed at the credentials, got %v", env)
+	}
+
+	if clock.after != time.Hour-aws.AuthExpiryWindow {
+		t.Fatalf("Expected the credentials to be renewed ahead of expiry, scheduled after %v", clock.after)
+	}
+
+	// The build outlasts the first session
+	clock.fire()
+
+	if !strings.Contains(readCredentials(t, dir), "aws_session_token = two") {
+		t.Error("Expected the credentials to be renewed")
+	}
+
+	if clock.f == nil || clock.after != time.Hour-aws.AuthExpiryWindow {
+		t.Errorf("Expected the renewed credentials to be renewed in turn, scheduled after %v", clock.after)
+	}
+
+	creds.Stop()
+
+	if !clock.stopped {
+		t.Error("Expected renewing to stop")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, credentialsFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected the credentials to be removed, got %v", err)
+	}
+}
+
+func TestCredentialsExpire(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	defer withFakeClock(clock)()
+	defer withSessions(clock, session{token: "one", duration: time.Hour})()
+	defer withRegistry(t)()
+	dir, cleanup := withDir(t)
+	defer cleanup()
+
+	registry.Default.Create("abc", "base", registry.Source{}, "123", []string{"eu-west-1"})
+
+	expires := clock.now.Add(time.Hour)
+	cancelled := false
+	creds, err := startCredentials("abc", "123", dir, map[string]*packer.Variable{}, func() { cancelled = true })
+	if err != nil {
+		t.Fatalf("Unable to start credentials: %v", err)
+	}
+	defer creds.Stop()
+
+	// Renewing fails, so is retried until the credentials expire
+	renewals := 0
+	for !cancelled && clock.f != nil {
+		clock.fire()
+		renewals++
+	}
+
+	if !cancelled {
+		t.Fatal("Expected the build to be cancelled once its credentials expire")
+	}
+
+	if !clock.now.Equal(expires) || renewals < 3 {
+		t.Errorf("Expected renewing to be retried until expiry, cancelled %v early after %d tries", expires.Sub(clock.now), renewals)
+	}
+
+	b, _ := registry.Default.Get("abc")
+	if len(b.Errors["bakery"]) == 0 {
+		t.Errorf("Expected the expiry to be recorded, got %v", b.Errors)
+	}
+}
+
+func TestStaticCredentials(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	defer withFakeClock(clock)()
+	defer withSessions(clock, session{})()
+	dir, cleanup := withDir(t)
+	defer cleanup()
+
+	creds, err := startCredentials("abc", "123", dir, map[string]*packer.Variable{}, func() {
+		t.Error("Expected the build not to be cancelled")
+	})
+
+	if err != nil {
+		t.Fatalf("Unable to start credentials: %v", err)
+	}
+	defer creds.Stop()
+
+	if clock.f != nil {
+		t.Errorf("Expected static credentials not to be renewed, scheduled after %v", clock.after)
+	}
+
+	if strings.Contains(readCredentials(t, dir), "aws_session_token") {
+		t.Error("Expected static credentials to have no session token")
+	}
+}
+
+func TestCredentialsUnavailable(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	defer withFakeClock(clock)()
+	defer withSessions(clock, session{err: fmt.Errorf("Access denied")})()
+	dir, cleanup := withDir(t)
+	defer cleanup()
+
+	if _, err := startCredentials("abc", "123", dir, map[string]*packer.Variable{}, func() {}); err == nil {
+		t.Fatal("Expected the build not to start without credentials")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, credentialsFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no credentials to be written, got %v", err)
+	}
+}
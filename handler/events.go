@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/hailocab/bakery-service/events"
+	"github.com/hailocab/bakery-service/packer/ui"
+	"github.com/hailocab/bakery-service/registry"
+
+	log "github.com/cihub/seelog"
+)
+
+// newTracker derives a build's artifacts and builder progress from its
+// machine readable output. Artifacts are recorded as each builder
+// finishes rather than once every builder has. Builders don't write
+// artifacts themselves, Packer writes them from each builder's result as
+// it returns, including builders that fail or crash, so every builder is
+// tracked even if the results of the whole build are lost
+func newTracker(id string) *ui.Tracker {
+	t := ui.NewTracker()
+
+	t.OnArtifact = func(a *ui.Artifact) {
+		err := registry.Default.AddArtifacts(id, newArtifact(&registry.Artifact{
+			Builder:     a.Builder,
+			BuilderID:   a.BuilderID,
+			ID:          a.ID,
+			Files:       a.Files,
+			Description: a.Description,
+		}))
+
+		if err != nil {
+			log.Errorf("[%s] Unable to record artifact %q: %v", id, a.ID, err)
+		}
+
+		e := events.New(events.TypeArtifact, id)
+		e.Builder = a.Builder
+		e.Artifacts = []string{a.ID}
+
+		events.Publish(e)
+	}
+
+	t.OnFinish = func(p *ui.Progress) {
+		e := events.New(events.TypeBuilderFinished, id)
+		e.Builder = p.Builder
+
+		for _, a := range p.Artifacts {
+			e.Artifacts = append(e.Artifacts, a.ID)
+		}
+
+		if len(p.Errors) > 0 {
+			e.Errors = map[string]string{p.Builder: strings.Join(p.Errors, "\n")}
+		}
+
+		events.Publish(e)
+	}
+
+	return t
+}
+
+// buildFinished publishes the outcome of a build, if it has finished
+func buildFinished(id string) {
+	b, err := registry.Default.Get(id)
+	if err != nil {
+		return
+	}
+
+	var t string
+	switch b.State {
+	case registry.StateSucceeded:
+		t = events.TypeSucceeded
+	case registry.StateFailed:
+		t = events.TypeFailed
+	case registry.StateCancelled:
+		t = events.TypeCancelled
+	default:
+		return
+	}
+
+	e := events.New(t, id)
+	e.Errors = b.Errors
+	e.LogURL = b.LogURL
+	e.LogErrors = b.LogErrors
+
+	for _, a := range b.Artifacts {
+		e.Artifacts = append(e.Artifacts, a.ID)
+	}
+
+	events.Publish(e)
+}
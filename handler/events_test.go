@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hailocab/bakery-service/events"
+	"github.com/hailocab/bakery-service/packer/ui"
+	"github.com/hailocab/bakery-service/registry"
+)
+
+// fakePublisher keeps every event published
+type fakePublisher struct {
+	sync.Mutex
+	events []*events.Event
+}
+
+func (f *fakePublisher) Publish(e *events.Event) error {
+	f.Lock()
+	defer f.Unlock()
+
+	f.events = append(f.events, e)
+	return nil
+}
+
+// withPublisher makes a fake publisher the default, returning a func
+// restoring the previous one
+func withPublisher() (*fakePublisher, func()) {
+	p := &fakePublisher{}
+
+	orig := events.Default
+	events.Default = p
+
+	return p, func() { events.Default = orig }
+}
+
+func TestBuildFinished(t *testing.T) {
+	defer withRegistry(t)()
+	p, restore := withPublisher()
+	defer restore()
+
+	reg := registry.Default
+	reg.Create("abc", "base", registry.Source{}, "123", []string{"eu-west-1"})
+	reg.Update("abc", func(b *registry.Build) {
+		b.LogURL = "s3://hailo-bakery/logs/abc.log"
+		b.LogErrors = []string{"Unable to index 1 of 10 messages"}
+	})
+	reg.Finish("abc", []*registry.Artifact{{Builder: "amazon-ebs", ID: "eu-west-1:ami-123"}}, nil)
+
+	buildFinished("abc")
+
+	if len(p.events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(p.events))
+	}
+
+	e := p.events[0]
+	if e.Type != events.TypeSucceeded || len(e.Artifacts) != 1 || e.Artifacts[0] != "eu-west-1:ami-123" {
+		t.Errorf("Unexpected event %#v", e)
+	}
+
+	if e.LogURL != "s3://hailo-bakery/logs/abc.log" || len(e.LogErrors) != 1 {
+		t.Errorf("Expected the event to say where the log went, got %q %v", e.LogURL, e.LogErrors)
+	}
+}
+
+func TestTrackerEvents(t *testing.T) {
+	defer withRegistry(t)()
+	p, restore := withPublisher()
+	defer restore()
+
+	registry.Default.Create("abc", "base", registry.Source{}, "123", []string{"eu-west-1", "us-east-1"})
+
+	out := ui.New("abc", ui.AddCaller("tracker", newTracker("abc")))
+	ebs := out.ForBuilder("amazon-ebs")
+	ebs.Machine("artifact-count", "1")
+	ebs.Machine("artifact", "0", "builder-id", "mitchellh.amazonebs")
+	ebs.Machine("artifact", "0", "id", "eu-west-1:ami-123,us-east-1:ami-456")
+	ebs.Machine("artifact", "0", "end")
+	out.ForBuilder("docker").Machine("error", "Unable to pull image")
+	out.Close()
+
+	b, _ := registry.Default.Get("abc")
+	if len(b.Artifacts) != 2 || b.Artifacts[0].AMI != "ami-123" || b.Artifacts[1].Region != "us-east-1" {
+		t.Fatalf("Expected an artifact per region to be recorded, got %#v", b.Artifacts)
+	}
+
+	var types []string
+	finished := map[string]*events.Event{}
+	for _, e := range p.events {
+		types = append(types, e.Type)
+		if e.Type == events.TypeBuilderFinished {
+			finished[e.Builder] = e
+		}
+	}
+
+	if len(p.events) != 3 || p.events[0].Type != events.TypeArtifact {
+		t.Fatalf("Unexpected events %v", types)
+	}
+
+	ebsFinished := finished["amazon-ebs"]
+	if ebsFinished == nil || len(ebsFinished.Artifacts) != 1 || len(ebsFinished.Errors) != 0 {
+		t.Errorf("Expected amazon-ebs to finish with its artifact, got %#v", ebsFinished)
+	}
+
+	dockerFinished := finished["docker"]
+	if dockerFinished == nil || dockerFinished.Errors["docker"] != "Unable to pull image" {
+		t.Errorf("Expected docker to finish with its error, got %#v", dockerFinished)
+	}
+}
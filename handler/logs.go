@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	protoLogs "github.com/hailocab/bakery-service/proto/logs"
+
+	"github.com/hailocab/bakery-service/logs"
+	"github.com/hailocab/bakery-service/packer/ui"
+	"github.com/hailocab/bakery-service/registry"
+
+	"github.com/hailocab/go-platform-layer/errors"
+	"github.com/hailocab/go-platform-layer/server"
+
+	"github.com/hailocab/protobuf/proto"
+)
+
+const (
+	// LogsEndpoint name of endpoint
+	LogsEndpoint = "com.hailocab.infrastructure.bakery.logs"
+)
+
+// Logs endpoint, returns the messages of a build in order. The cursor
+// returned is the sequence of the last message, it can be passed as since
+// to tail a running build
+func Logs(req *server.Request) (proto.Message, errors.Error) {
+	request := req.Data().(*protoLogs.Request)
+
+	if _, err := registry.Default.Get(request.GetId()); err == registry.ErrNotFound {
+		return nil, errors.NotFound(LogsEndpoint,
+			fmt.Sprintf("Unknown build %q", request.GetId()),
+		)
+	}
+
+	for _, t := range request.GetTypes() {
+		if !ui.ValidType(t) {
+			return nil, errors.BadRequest(LogsEndpoint,
+				fmt.Sprintf("Unknown message type %q", t),
+			)
+		}
+	}
+
+	q := &logs.Query{
+		ID:      request.GetId(),
+		Types:   request.GetTypes(),
+		Builder: request.GetBuilder(),
+		Offset:  int(request.GetOffset()),
+		Limit:   int(request.GetLimit()),
+	}
+
+	if q.Offset < 0 {
+		return nil, errors.BadRequest(LogsEndpoint, "Offset can't be negative")
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = logs.DefaultLimit
+	}
+
+	if q.Limit > logs.MaxLimit {
+		q.Limit = logs.MaxLimit
+	}
+
+	if since := request.GetSince(); len(since) > 0 {
+		seq, err := strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			return nil, errors.BadRequest(LogsEndpoint,
+				fmt.Sprintf("Invalid cursor %q", since),
+			)
+		}
+
+		q.Since = seq
+	}
+
+	msgs, err := logs.Default.Read(q)
+	if err == logs.ErrNotFound {
+		return nil, errors.NotFound(LogsEndpoint,
+			fmt.Sprintf("No log for build %q", request.GetId()),
+		)
+	}
+
+	if err != nil {
+		return nil, errors.InternalServerError(LogsEndpoint, err)
+	}
+
+	rsp := &protoLogs.Response{}
+	if len(request.GetSince()) > 0 {
+		rsp.Cursor = proto.String(request.GetSince())
+	}
+
+	for _, m := range msgs {
+		msg := &protoLogs.Message{
+			Date:    proto.String(m.Date.Format(time.RFC3339Nano)),
+			Type:    proto.String(m.Type.String()),
+			Message: proto.String(m.Message),
+		}
+
+		if len(m.Builder) > 0 {
+			msg.Builder = proto.String(m.Builder)
+		}
+
+		rsp.Messages = append(rsp.Messages, msg)
+
+		rsp.Cursor = proto.String(strconv.FormatUint(m.Sequence, 10))
+	}
+
+	return rsp, nil
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"github.com/hailocab/bakery-service/events"
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/packer/ui"
+	"github.com/hailocab/bakery-service/registry"
+	"github.com/hailocab/bakery-service/storage"
+	"github.com/hailocab/bakery-service/workspace"
+
+	log "github.com/cihub/seelog"
+)
+
+// run performs a build in the background, recording its progress in the registry
+func run(id string, accountID string, dir string, p *packer.Packer, vars map[string]*packer.Variable, out *ui.UI, logArchive *ui.S3Caller, logIndex *ui.ElasticCaller) {
+	reg := registry.Default
+
+	// Deferred in reverse: the output is flushed, then indexed and
+	// archived, so the final event carries where the log went
+	defer release(id)
+	defer buildFinished(id)
+	defer archive(id, logArchive)
+	defer index(id, logIndex)
+	defer out.Close()
+
+	// Cancelled while waiting in the queue
+	if b, err := reg.Get(id); err != nil || b.State.Finished() {
+		log.Infof("[%s] Skipping build", id)
+		return
+	}
+
+	if err := reg.SetState(id, registry.StatePreparing); err != nil {
+		log.Errorf("[%s] Unable to update build: %v", id, err)
+	}
+
+	events.Publish(events.New(events.TypeStarted, id))
+
+	// Credentials assumed when the build was requested may have expired
+	// while it was queued, so the build starts with a full session
+	creds, err := startCredentials(id, accountID, dir, vars, p.Cancel)
+	if err != nil {
+		fail(id, err)
+		return
+	}
+
+	defer creds.Stop()
+	p.Env = creds.Env()
+
+	core, err := p.NewCore(vars)
+	if err != nil {
+		fail(id, err)
+		return
+	}
+
+	builds, err := p.ListBuilds(core)
+	if err != nil {
+		fail(id, err)
+		return
+	}
+
+	if err := reg.SetCanceller(id, p.Cancel); err != nil {
+		log.Infof("[%s] Not running build: %v", id, err)
+
+		if err := reg.Finish(id, nil, nil); err != nil {
+			log.Errorf("[%s] Unable to record build result: %v", id, err)
+		}
+
+		return
+	}
+
+	if err := reg.SetState(id, registry.StateRunning); err != nil {
+		log.Errorf("[%s] Unable to update build: %v", id, err)
+	}
+
+	results := p.ProcessBuilds(builds)
+	creds.Stop()
+
+	for _, r := range results {
+		log.Infof("[%s] Build %q took %v with %d warnings", id, r.Name, r.Duration, len(r.Warnings))
+	}
+
+	// Artifacts tracked from the output are recorded before the results
+	out.Close()
+
+	if err := reg.Finish(id, newArtifacts(results.Artifacts()), results.Errors()); err != nil {
+		log.Errorf("[%s] Unable to record build result: %v", id, err)
+	}
+}
+
+func fail(id string, err error) {
+	log.Errorf("[%s] Build failed: %v", id, err)
+
+	if err := registry.Default.Fail(id, "bakery", err); err != nil {
+		log.Errorf("[%s] Unable to record build failure: %v", id, err)
+	}
+}
+
+// archive completes the build's log upload and records where it is
+func archive(id string, logArchive *ui.S3Caller) {
+	obj, err := logArchive.Close()
+	if err != nil {
+		log.Errorf("[%s] %v", id, err)
+		return
+	}
+
+	if obj == nil {
+		return
+	}
+
+	err = registry.Default.Update(id, func(b *registry.Build) {
+		b.LogURL = storage.Default.URL(obj.Key)
+	})
+
+	if err != nil {
+		log.Errorf("[%s] Unable to record build log: %v", id, err)
+	}
+}
+
+// index writes the build's last messages to elastic search, recording any
+// that couldn't be indexed
+func index(id string, logIndex *ui.ElasticCaller) {
+	indexErr := logIndex.Close()
+	if indexErr == nil {
+		return
+	}
+
+	log.Errorf("[%s] %v", id, indexErr)
+
+	err := registry.Default.Update(id, func(b *registry.Build) {
+		b.LogErrors = append(b.LogErrors, indexErr.Error())
+	})
+
+	if err != nil {
+		log.Errorf("[%s] Unable to record log errors: %v", id, err)
+	}
+}
+
+// release hands the build's workspace back, keeping it if the build failed
+func release(id string) {
+	failed := true
+	if b, err := registry.Default.Get(id); err == nil {
+		failed = b.State == registry.StateFailed
+	}
+
+	if err := workspace.Default.Release(id, failed); err != nil {
+		log.Errorf("[%s] Unable to release workspace: %v", id, err)
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	protoStatus "github.com/hailocab/bakery-service/proto/status"
+
+	"github.com/hailocab/bakery-service/registry"
+	"github.com/hailocab/bakery-service/workspace"
+
+	"github.com/hailocab/go-platform-layer/errors"
+	"github.com/hailocab/go-platform-layer/server"
+
+	"github.com/hailocab/protobuf/proto"
+)
+
+const (
+	// StatusEndpoint name of endpoint
+	StatusEndpoint = "com.hailocab.infrastructure.bakery.status"
+)
+
+// Status endpoint
+func Status(req *server.Request) (proto.Message, errors.Error) {
+	request := req.Data().(*protoStatus.Request)
+
+	b, err := registry.Default.Get(request.GetId())
+	if err == registry.ErrNotFound {
+		return nil, errors.NotFound(StatusEndpoint,
+			fmt.Sprintf("Unknown build %q", request.GetId()),
+		)
+	}
+
+	if err != nil {
+		return nil, errors.InternalServerError(StatusEndpoint, err)
+	}
+
+	rsp := &protoStatus.Response{
+		Id:       proto.String(b.ID),
+		Template: proto.String(b.Template),
+		State:    proto.String(b.State.String()),
+		Created:  timestamp(b.Created),
+		Started:  timestamp(b.Started),
+		Ended:    timestamp(b.Ended),
+		Source: &protoStatus.TemplateSource{
+			Key:      proto.String(b.Source.Key),
+			Version:  proto.String(b.Source.Version),
+			Etag:     proto.String(b.Source.ETag),
+			Checksum: proto.String(b.Source.Checksum),
+		},
+		Regions:   b.Regions,
+		LogErrors: b.LogErrors,
+	}
+
+	if len(b.Account) > 0 {
+		rsp.AccountId = proto.String(b.Account)
+	}
+
+	if len(b.LogURL) > 0 {
+		rsp.LogUrl = proto.String(b.LogURL)
+	}
+
+	for n, e := range b.Errors {
+		rsp.Errors = append(rsp.Errors, &protoStatus.BuilderError{
+			Builder: proto.String(n),
+			Error:   proto.String(e),
+		})
+	}
+
+	for _, a := range b.Artifacts {
+		rsp.Artifacts = append(rsp.Artifacts, &protoStatus.Artifact{
+			Builder:     proto.String(a.Builder),
+			Id:          proto.String(a.ID),
+			Description: proto.String(a.Description),
+		})
+	}
+
+	// Workspaces only exist while building, or after failing
+	if size, err := workspace.Default.Usage(b.ID); err == nil {
+		rsp.WorkspaceBytes = proto.Int64(size)
+	}
+
+	return rsp, nil
+}
+
+func timestamp(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+
+	return proto.Int64(t.Unix())
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/registry"
+	"github.com/hailocab/bakery-service/storage"
+)
+
+// fetchTemplate downloads a template bundle. Every known archive format
+// is tried unless format is set.
+//
+// A version is looked up as templates/<name>/<version>.<ext> first, then
+// as a storage version of templates/<name>.<ext>
+func fetchTemplate(name string, format string, version string) (*storage.Object, error) {
+	var extensions []string
+	if len(format) > 0 {
+		f, ok := packer.FormatByName(format)
+		if !ok {
+			return nil, fmt.Errorf("Unknown template format %q", format)
+		}
+
+		extensions = f.Extensions
+	} else {
+		for _, f := range packer.Formats() {
+			extensions = append(extensions, f.Extensions...)
+		}
+	}
+
+	type candidate struct {
+		key     string
+		version string
+	}
+
+	var candidates []candidate
+	if len(version) > 0 {
+		for _, ext := range extensions {
+			candidates = append(candidates, candidate{
+				key: fmt.Sprintf("%s/%s/%s%s", BucketTemplatePath, name, version, ext),
+			})
+		}
+	}
+
+	for _, ext := range extensions {
+		candidates = append(candidates, candidate{
+			key:     fmt.Sprintf("%s/%s%s", BucketTemplatePath, name, ext),
+			version: version,
+		})
+	}
+
+	// Only a missing candidate moves on to the next one, anything else
+	// would fail the same way for every extension
+	for _, c := range candidates {
+		obj, err := storage.Default.Get(c.key, c.version)
+		if err == storage.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return obj, nil
+	}
+
+	if len(version) > 0 {
+		return nil, fmt.Errorf("Template %q has no version %q", name, version)
+	}
+
+	return nil, fmt.Errorf("Template %q not found", name)
+}
+
+// extractTemplate unpacks a template bundle into dir, returning where it
+// came from along with the checksum of its bytes
+func extractTemplate(obj *storage.Object, dir string) (registry.Source, error) {
+	defer obj.Body.Close()
+
+	h := sha256.New()
+	r := io.TeeReader(obj.Body, h)
+
+	if err := packer.Extract(r, obj.Key, dir); err != nil {
+		return registry.Source{}, err
+	}
+
+	// Formats like tar may stop reading before the end of the object
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return registry.Source{}, err
+	}
+
+	return registry.Source{
+		Key:      obj.Key,
+		Version:  obj.Version,
+		ETag:     obj.ETag,
+		Checksum: "sha256:" + hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
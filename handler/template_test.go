@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	protoTemplates "github.com/hailocab/bakery-service/proto/templates"
+
+	"github.com/hailocab/bakery-service/storage"
+
+	"github.com/hailocab/protobuf/proto"
+)
+
+// fakeStore records the keys fetched from it, failing every fetch with err
+type fakeStore struct {
+	storage.Store
+	err  error
+	gets []string
+}
+
+func (f *fakeStore) Get(key string, version string) (*storage.Object, error) {
+	f.gets = append(f.gets, key)
+	return nil, f.err
+}
+
+// withStore makes s the default store, returning a func restoring it
+func withStore(s storage.Store) func() {
+	orig := storage.Default
+	storage.Default = s
+
+	return func() { storage.Default = orig }
+}
+
+func TestFetchTemplateError(t *testing.T) {
+	denied := fmt.Errorf("Access denied")
+	store := &fakeStore{err: denied}
+	defer withStore(store)()
+
+	if _, err := fetchTemplate("base", "", ""); err != denied {
+		t.Fatalf("Expected the storage error, got %v", err)
+	}
+
+	if len(store.gets) != 1 {
+		t.Errorf("Expected to stop after the first fetch, fetched %v", store.gets)
+	}
+}
+
+func TestFetchTemplateNotFound(t *testing.T) {
+	store := &fakeStore{err: storage.ErrNotFound}
+	defer withStore(store)()
+
+	_, err := fetchTemplate("base", "zip", "")
+	if err == nil || err.Error() != `Template "base" not found` {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(store.gets) == 0 {
+		t.Error("Expected every candidate to be fetched")
+	}
+}
+
+// versionedStore keeps object versions under versions/<key>/<version>
+// of a local store, the way S3 keeps version IDs
+type versionedStore struct {
+	*storage.LocalStore
+}
+
+func (v *versionedStore) Get(key string, version string) (*storage.Object, error) {
+	if len(version) == 0 {
+		return v.LocalStore.Get(key, "")
+	}
+
+	obj, err := v.LocalStore.Get(fmt.Sprintf("versions/%s/%s", key, version), "")
+	if err != nil {
+		return nil, err
+	}
+
+	obj.Key = key
+	obj.Version = version
+
+	return obj, nil
+}
+
+func newLocalStore(t *testing.T) (*storage.LocalStore, func()) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatalf("Unable to create root: %v", err)
+	}
+
+	s, err := storage.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+
+	return s, func() { os.RemoveAll(dir) }
+}
+
+// putBundle stores a zip holding a template that describes itself
+func putBundle(t *testing.T, s storage.Store, key string, desc string) []byte {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("base.json")
+	if err != nil {
+		t.Fatalf("Unable to create template: %v", err)
+	}
+
+	fmt.Fprintf(f, `{"description": %q, "builders": [{"type": "amazon-ebs"}]}`, desc)
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unable to write zip: %v", err)
+	}
+
+	if _, err := s.Put(key, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Unable to put %q: %v", key, err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFetchTemplateVersions(t *testing.T) {
+	local, cleanup := newLocalStore(t)
+	defer cleanup()
+	defer withStore(&versionedStore{local})()
+
+	putBundle(t, local, "templates/base.zip", "latest")
+	putBundle(t, local, "templates/base/v2.zip", "v2")
+	putBundle(t, local, "versions/templates/base.zip/abc123", "abc123")
+
+	testCases := []struct {
+		version     string
+		key         string
+		objVersion  string
+		description string
+	}{
+		{"", "templates/base.zip", "", "latest"},
+
+		// Versions in their own directory win over object versions
+		{"v2", "templates/base/v2.zip", "", "v2"},
+		{"abc123", "templates/base.zip", "abc123", "abc123"},
+	}
+
+	for _, tc := range testCases {
+		obj, err := fetchTemplate("base", "", tc.version)
+		if err != nil {
+			t.Fatalf("Unable to fetch version %q: %v", tc.version, err)
+		}
+
+		body, err := ioutil.ReadAll(obj.Body)
+		obj.Body.Close()
+		if err != nil {
+			t.Fatalf("Unable to read version %q: %v", tc.version, err)
+		}
+
+		if obj.Key != tc.key || obj.Version != tc.objVersion {
+			t.Errorf("Expected version %q to be %s@%q, got %s@%q", tc.version, tc.key, tc.objVersion, obj.Key, obj.Version)
+		}
+
+		if !bytes.Contains(body, []byte(tc.description)) {
+			t.Errorf("Expected version %q to be the %q bundle", tc.version, tc.description)
+		}
+	}
+
+	if _, err := fetchTemplate("base", "", "missing"); err == nil {
+		t.Error("Expected an unknown version to error")
+	}
+
+	defer withWorkspace(t)()
+
+	// Describing a version says which version it is, whichever way it's kept
+	for _, tc := range testCases {
+		rsp, err := describeTemplate(&protoTemplates.DescribeRequest{
+			Template: proto.String("base"),
+			Version:  proto.String(tc.version),
+		})
+
+		if err != nil {
+			t.Fatalf("Unable to describe version %q: %v", tc.version, err)
+		}
+
+		if rsp.GetVersion() != tc.version || rsp.GetKey() != tc.key || rsp.GetDescription() != tc.description {
+			t.Errorf("Expected version %q to be described, got %s@%q %q", tc.version, rsp.GetKey(), rsp.GetVersion(), rsp.GetDescription())
+		}
+	}
+}
+
+func TestExtractTemplateSource(t *testing.T) {
+	local, cleanup := newLocalStore(t)
+	defer cleanup()
+	defer withStore(local)()
+
+	bundle := putBundle(t, local, "templates/base/v2.zip", "v2")
+
+	obj, err := fetchTemplate("base", "zip", "v2")
+	if err != nil {
+		t.Fatalf("Unable to fetch: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatalf("Unable to create workspace: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source, err := extractTemplate(obj, dir)
+	if err != nil {
+		t.Fatalf("Unable to extract: %v", err)
+	}
+
+	sum := sha256.Sum256(bundle)
+	if source.Checksum != "sha256:"+hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected the checksum of the bundle, got %q", source.Checksum)
+	}
+
+	if source.Key != "templates/base/v2.zip" || len(source.ETag) == 0 || source.ETag != obj.ETag {
+		t.Errorf("Unexpected source %#v", source)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "base.json")); err != nil {
+		t.Errorf("Expected the template to be extracted: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	protoTemplates "github.com/hailocab/bakery-service/proto/templates"
+
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/storage"
+	"github.com/hailocab/bakery-service/workspace"
+
+	"github.com/hailocab/go-platform-layer/errors"
+	"github.com/hailocab/go-platform-layer/server"
+
+	log "github.com/cihub/seelog"
+	"github.com/hailocab/protobuf/proto"
+	"github.com/nu7hatch/gouuid"
+)
+
+const (
+	// TemplatesListEndpoint name of endpoint
+	TemplatesListEndpoint = "com.hailocab.infrastructure.bakery.templates.list"
+
+	// TemplatesDescribeEndpoint name of endpoint
+	TemplatesDescribeEndpoint = "com.hailocab.infrastructure.bakery.templates.describe"
+)
+
+// TemplatesList endpoint
+func TemplatesList(req *server.Request) (proto.Message, errors.Error) {
+	request := req.Data().(*protoTemplates.ListRequest)
+
+	prefix := BucketTemplatePath + "/"
+	objects, err := storage.Default.List(prefix + request.GetPrefix())
+	if err != nil {
+		return nil, errors.InternalServerError(TemplatesListEndpoint, err)
+	}
+
+	rsp := &protoTemplates.ListResponse{}
+	for _, o := range objects {
+		f, ok := packer.FormatForKey(o.Key)
+		if !ok {
+			continue
+		}
+
+		// Either <name>.<ext> or <name>/<version>.<ext>
+		path := strings.TrimPrefix(o.Key, prefix)
+		for _, ext := range f.Extensions {
+			if strings.HasSuffix(path, ext) {
+				path = strings.TrimSuffix(path, ext)
+				break
+			}
+		}
+
+		t := &protoTemplates.Template{
+			Name:         proto.String(path),
+			Key:          proto.String(o.Key),
+			Format:       proto.String(f.Name),
+			Size:         proto.Int64(o.Size),
+			LastModified: proto.Int64(o.LastModified.Unix()),
+		}
+
+		if i := strings.LastIndex(path, "/"); i >= 0 {
+			t.Name = proto.String(path[:i])
+			t.Version = proto.String(path[i+1:])
+		}
+
+		rsp.Templates = append(rsp.Templates, t)
+	}
+
+	return rsp, nil
+}
+
+// TemplatesDescribe endpoint
+func TemplatesDescribe(req *server.Request) (proto.Message, errors.Error) {
+	rsp, err := describeTemplate(req.Data().(*protoTemplates.DescribeRequest))
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp, nil
+}
+
+func describeTemplate(request *protoTemplates.DescribeRequest) (*protoTemplates.DescribeResponse, errors.Error) {
+	template := request.GetTemplate()
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, errors.InternalServerError(TemplatesDescribeEndpoint,
+			fmt.Sprintf("Unable to create ID: %v", err),
+		)
+	}
+
+	dir, err := workspace.Default.Create(id.String())
+	if err != nil {
+		return nil, errors.InternalServerError(TemplatesDescribeEndpoint, err)
+	}
+
+	defer func() {
+		if err := workspace.Default.Release(id.String(), false); err != nil {
+			log.Errorf("[%s] Unable to release workspace: %v", id.String(), err)
+		}
+	}()
+
+	obj, err := fetchTemplate(template, request.GetFormat(), request.GetVersion())
+	if err != nil {
+		return nil, errors.BadRequest(TemplatesDescribeEndpoint,
+			fmt.Sprintf("Unable to get object: %v", err),
+		)
+	}
+
+	source, err := extractTemplate(obj, dir)
+	if err != nil {
+		return nil, errors.InternalServerError(TemplatesDescribeEndpoint, err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("%s.json", template)))
+	if err != nil {
+		return nil, errors.BadRequest(TemplatesDescribeEndpoint,
+			fmt.Sprintf("Bundle doesn't contain %s.json", template),
+		)
+	}
+
+	p, err := packer.New(f, nil)
+	if err != nil {
+		return nil, errors.BadRequest(TemplatesDescribeEndpoint, err.Error())
+	}
+
+	// Versions kept in their own directory aren't object versions
+	version := source.Version
+	if len(version) == 0 {
+		version = request.GetVersion()
+	}
+
+	rsp := &protoTemplates.DescribeResponse{
+		Template:    proto.String(template),
+		Key:         proto.String(source.Key),
+		Version:     proto.String(version),
+		Description: proto.String(p.Template.Description),
+	}
+
+	for _, b := range p.Template.Builders {
+		rsp.Builders = append(rsp.Builders, &protoTemplates.Builder{
+			Name: proto.String(b.Name),
+			Type: proto.String(b.Type),
+		})
+	}
+
+	sort.Sort(buildersByName(rsp.Builders))
+
+	for _, pr := range p.Template.Provisioners {
+		rsp.Provisioners = append(rsp.Provisioners, pr.Type)
+	}
+
+	for _, seq := range p.Template.PostProcessors {
+		for _, pp := range seq {
+			rsp.PostProcessors = append(rsp.PostProcessors, pp.Type)
+		}
+	}
+
+	vars := p.ListTemplateVariables()
+	names := make([]string, 0, len(vars))
+	for n := range vars {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		v := &protoTemplates.Variable{
+			Name:      proto.String(n),
+			Required:  proto.Bool(vars[n].Required),
+			Automatic: proto.Bool(isAutomatic(n)),
+		}
+
+		if !vars[n].Required {
+			v.Default = proto.String(vars[n].Default)
+		}
+
+		rsp.Variables = append(rsp.Variables, v)
+	}
+
+	return rsp, nil
+}
+
+type buildersByName []*protoTemplates.Builder
+
+func (b buildersByName) Len() int           { return len(b) }
+func (b buildersByName) Less(i, j int) bool { return b[i].GetName() < b[j].GetName() }
+func (b buildersByName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	protoUpload "github.com/hailocab/bakery-service/proto/upload"
+
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/storage"
+	"github.com/hailocab/bakery-service/workspace"
+
+	"github.com/hailocab/go-platform-layer/errors"
+	"github.com/hailocab/go-platform-layer/server"
+
+	log "github.com/cihub/seelog"
+	"github.com/hailocab/protobuf/proto"
+	"github.com/nu7hatch/gouuid"
+)
+
+const (
+	// UploadEndpoint name of endpoint
+	UploadEndpoint = "com.hailocab.infrastructure.bakery.upload"
+
+	// BucketStagingPath storage path where bundles are staged for upload
+	BucketStagingPath = "staging"
+)
+
+var (
+	// discoverPlugins finds the plugins templates can use, replaced in tests
+	discoverPlugins = func() (*packer.Config, error) {
+		config := packer.NewConfig(packer.PluginMinPort, packer.PluginMaxPort)
+		if err := config.Discover(); err != nil {
+			return nil, err
+		}
+
+		return config, nil
+	}
+)
+
+// Upload endpoint, validates a template bundle and publishes it to
+// templates/<name>.zip
+func Upload(req *server.Request) (proto.Message, errors.Error) {
+	rsp, err := upload(req.Data().(*protoUpload.Request))
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp, nil
+}
+
+func upload(request *protoUpload.Request) (*protoUpload.Response, errors.Error) {
+	template := request.GetTemplate()
+
+	if len(template) == 0 || strings.ContainsAny(template, "/\\") || strings.Contains(template, "..") {
+		return nil, errors.BadRequest(UploadEndpoint,
+			fmt.Sprintf("Invalid template name %q", template),
+		)
+	}
+
+	data := request.GetBundle()
+	key := "bundle"
+
+	switch {
+	case len(data) > 0 && len(request.GetStagingKey()) > 0:
+		return nil, errors.BadRequest(UploadEndpoint, "Only one of bundle and staging_key can be set")
+	case len(request.GetStagingKey()) > 0:
+		if !isStaged(request.GetStagingKey()) {
+			return nil, errors.BadRequest(UploadEndpoint,
+				fmt.Sprintf("Staging key %q is not below %s/", request.GetStagingKey(), BucketStagingPath),
+			)
+		}
+
+		obj, err := storage.Default.Get(request.GetStagingKey(), "")
+		if err != nil {
+			return nil, errors.BadRequest(UploadEndpoint,
+				fmt.Sprintf("Unable to get object: %v", err),
+			)
+		}
+
+		data, err = readBundle(obj.Body)
+		if err != nil {
+			return nil, errors.BadRequest(UploadEndpoint, err.Error())
+		}
+
+		key = obj.Key
+	case len(data) == 0:
+		return nil, errors.BadRequest(UploadEndpoint, "One of bundle or staging_key is required")
+	}
+
+	if format := request.GetFormat(); len(format) > 0 {
+		f, ok := packer.FormatByName(format)
+		if !ok {
+			return nil, errors.BadRequest(UploadEndpoint,
+				fmt.Sprintf("Unknown template format %q", format),
+			)
+		}
+
+		key = "bundle" + f.Extensions[0]
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, errors.InternalServerError(UploadEndpoint,
+			fmt.Sprintf("Unable to create ID: %v", err),
+		)
+	}
+
+	dir, err := workspace.Default.Create(id.String())
+	if err != nil {
+		return nil, errors.InternalServerError(UploadEndpoint, err)
+	}
+
+	defer func() {
+		if err := workspace.Default.Release(id.String(), false); err != nil {
+			log.Errorf("[%s] Unable to release workspace: %v", id.String(), err)
+		}
+	}()
+
+	rsp := &protoUpload.Response{
+		Published: proto.Bool(false),
+	}
+
+	if err := packer.Extract(bytes.NewReader(data), key, dir); err != nil {
+		rsp.Errors = append(rsp.Errors, validationError("bundle", "", err.Error()))
+		return rsp, nil
+	}
+
+	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("%s.json", template)))
+	if err != nil {
+		rsp.Errors = append(rsp.Errors, validationError("bundle", "",
+			fmt.Sprintf("Bundle doesn't contain %s.json", template),
+		))
+		return rsp, nil
+	}
+
+	tpl, err := packer.ReadTemplate(f)
+	if err != nil {
+		rsp.Errors = append(rsp.Errors, validationError("template", "", err.Error()))
+		return rsp, nil
+	}
+
+	config, err := discoverPlugins()
+	if err != nil {
+		return nil, errors.InternalServerError(UploadEndpoint, err)
+	}
+
+	builders, provisioners, postProcessors := packer.UnknownComponents(config, tpl)
+	for _, b := range builders {
+		rsp.Errors = append(rsp.Errors, validationError("builder", b, fmt.Sprintf("Unknown builder %q", b)))
+	}
+
+	for _, p := range provisioners {
+		rsp.Errors = append(rsp.Errors, validationError("provisioner", p, fmt.Sprintf("Unknown provisioner %q", p)))
+	}
+
+	for _, p := range postProcessors {
+		rsp.Errors = append(rsp.Errors, validationError("post-processor", p, fmt.Sprintf("Unknown post processor %q", p)))
+	}
+
+	if len(rsp.Errors) > 0 {
+		return rsp, nil
+	}
+
+	// Templates are always published as zip, so repack anything else
+	format, ok := packer.FormatForKey(key)
+	if !ok {
+		format, _ = packer.DetectFormat(data)
+	}
+
+	if format == nil || format.Name != "zip" {
+		var buf bytes.Buffer
+		if err := packer.ZipDir(dir, &buf); err != nil {
+			return nil, errors.InternalServerError(UploadEndpoint, err)
+		}
+
+		data = buf.Bytes()
+	}
+
+	obj, err := storage.Default.Put(fmt.Sprintf("%s/%s.zip", BucketTemplatePath, template), bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.InternalServerError(UploadEndpoint, err)
+	}
+
+	log.Infof("Published template %q to %s (version %q)", template, obj.Key, obj.Version)
+
+	rsp.Published = proto.Bool(true)
+	rsp.Key = proto.String(obj.Key)
+	rsp.Version = proto.String(obj.Version)
+	rsp.Etag = proto.String(obj.ETag)
+
+	return rsp, nil
+}
+
+// isStaged reports whether key is below the staging path, so only bundles
+// staged for upload can be published
+func isStaged(key string) bool {
+	return strings.HasPrefix(key, BucketStagingPath+"/") && path.Clean(key) == key
+}
+
+// readBundle reads a staged bundle, refusing anything larger than the
+// archive size limit
+func readBundle(rc io.ReadCloser) ([]byte, error) {
+	defer rc.Close()
+
+	limit := packer.ArchiveLimits.MaxSize
+	if limit <= 0 {
+		return ioutil.ReadAll(rc)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("Bundle is larger than %d bytes", limit)
+	}
+
+	return data, nil
+}
+
+func validationError(t string, component string, message string) *protoUpload.ValidationError {
+	e := &protoUpload.ValidationError{
+		Type:    proto.String(t),
+		Message: proto.String(message),
+	}
+
+	if len(component) > 0 {
+		e.Component = proto.String(component)
+	}
+
+	return e
+}
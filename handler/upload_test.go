@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	protoUpload "github.com/hailocab/bakery-service/proto/upload"
+
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/workspace"
+
+	"github.com/hailocab/protobuf/proto"
+)
+
+const (
+	validTemplate = `{
+		"builders": [{"type": "amazon-ebs"}],
+		"provisioners": [{"type": "shell"}]
+	}`
+
+	unknownPluginsTemplate = `{
+		"builders": [{"type": "amazon-ebs"}, {"type": "qemu"}],
+		"provisioners": [{"type": "chef-solo"}]
+	}`
+)
+
+// withPlugins makes the plugins of config the only ones discovered,
+// returning a func restoring discovery
+func withPlugins(config *packer.Config) func() {
+	orig := discoverPlugins
+	discoverPlugins = func() (*packer.Config, error) { return config, nil }
+
+	return func() { discoverPlugins = orig }
+}
+
+// withWorkspace makes a temporary workspace manager the default,
+// returning a func restoring the previous one
+func withWorkspace(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "workspaces")
+	if err != nil {
+		t.Fatalf("Unable to create root: %v", err)
+	}
+
+	m, err := workspace.New(dir, 0)
+	if err != nil {
+		t.Fatalf("Unable to create workspace manager: %v", err)
+	}
+
+	orig := workspace.Default
+	workspace.Default = m
+
+	return func() {
+		workspace.Default = orig
+		os.RemoveAll(dir)
+	}
+}
+
+func zipBundle(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	for name, contents := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Unable to create %q: %v", name, err)
+		}
+
+		f.Write([]byte(contents))
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unable to write zip: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func tarGzBundle(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+	for name, contents := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))}
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatalf("Unable to create %q: %v", name, err)
+		}
+
+		w.Write([]byte(contents))
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unable to write tar: %v", err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Unable to write gzip: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestUpload(t *testing.T) {
+	local, cleanup := newLocalStore(t)
+	defer cleanup()
+	defer withStore(local)()
+	defer withWorkspace(t)()
+	defer withPlugins(&packer.Config{
+		Builders:     map[string]string{"amazon-ebs": "packer-builder-amazon-ebs"},
+		Provisioners: map[string]string{"shell": "packer-provisioner-shell"},
+	})()
+
+	staged := zipBundle(t, map[string]string{"base.json": validTemplate})
+	if _, err := local.Put("staging/base.zip", bytes.NewReader(staged)); err != nil {
+		t.Fatalf("Unable to stage bundle: %v", err)
+	}
+
+	if _, err := local.Put("templates/other.zip", bytes.NewReader(staged)); err != nil {
+		t.Fatalf("Unable to put template: %v", err)
+	}
+
+	testCases := []struct {
+		desc       string
+		request    *protoUpload.Request
+		badRequest bool
+		errors     []string
+	}{
+		{
+			desc: "zip bundle",
+			request: &protoUpload.Request{
+				Template: proto.String("base"),
+				Bundle:   zipBundle(t, map[string]string{"base.json": validTemplate}),
+			},
+		},
+		{
+			desc: "tar.gz bundle",
+			request: &protoUpload.Request{
+				Template: proto.String("base"),
+				Bundle:   tarGzBundle(t, map[string]string{"base.json": validTemplate, "scripts/setup.sh": "#!/bin/sh"}),
+			},
+		},
+		{
+			desc: "staged bundle",
+			request: &protoUpload.Request{
+				Template:   proto.String("base"),
+				StagingKey: proto.String("staging/base.zip"),
+			},
+		},
+		{
+			desc: "missing template",
+			request: &protoUpload.Request{
+				Template: proto.String("base"),
+				Bundle:   zipBundle(t, map[string]string{"other.json": validTemplate}),
+			},
+			errors: []string{"bundle"},
+		},
+		{
+			desc: "invalid template",
+			request: &protoUpload.Request{
+				Template: proto.String("base"),
+				Bundle:   zipBundle(t, map[string]string{"base.json": "{"}),
+			},
+			errors: []string{"template"},
+		},
+		{
+			desc: "unknown plugins",
+			request: &protoUpload.Request{
+				Template: proto.String("base"),
+				Bundle:   zipBundle(t, map[string]string{"base.json": unknownPluginsTemplate}),
+			},
+			errors: []string{"builder", "provisioner"},
+		},
+		{
+			desc: "invalid name",
+			request: &protoUpload.Request{
+				Template: proto.String("../base"),
+				Bundle:   zipBundle(t, map[string]string{"base.json": validTemplate}),
+			},
+			badRequest: true,
+		},
+		{
+			desc: "published template as staging key",
+			request: &protoUpload.Request{
+				Template:   proto.String("base"),
+				StagingKey: proto.String("templates/other.zip"),
+			},
+			badRequest: true,
+		},
+		{
+			desc: "staging key outside staging",
+			request: &protoUpload.Request{
+				Template:   proto.String("base"),
+				StagingKey: proto.String("staging/../templates/other.zip"),
+			},
+			badRequest: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		rsp, uploadErr := upload(tc.request)
+		if tc.badRequest {
+			if uploadErr == nil {
+				t.Errorf("%s: Expected the request to be rejected, got %v", tc.desc, rsp)
+			}
+
+			continue
+		}
+
+		if uploadErr != nil {
+			t.Fatalf("%s: Unable to upload: %v", tc.desc, uploadErr)
+		}
+
+		var errs []string
+		for _, e := range rsp.Errors {
+			errs = append(errs, e.GetType())
+		}
+
+		if a, b := strings.Join(errs, ","), strings.Join(tc.errors, ","); a != b {
+			t.Errorf("%s: Expected validation errors %q, got %q", tc.desc, b, a)
+		}
+
+		if rsp.GetPublished() != (len(tc.errors) == 0) {
+			t.Errorf("%s: Unexpected published %v", tc.desc, rsp.GetPublished())
+		}
+
+		if !rsp.GetPublished() {
+			continue
+		}
+
+		if rsp.GetKey() != "templates/base.zip" {
+			t.Errorf("%s: Expected the template to be published to templates/base.zip, got %q", tc.desc, rsp.GetKey())
+		}
+
+		// Every bundle is published as zip
+		obj, err := local.Get("templates/base.zip", "")
+		if err != nil {
+			t.Fatalf("%s: Unable to get published template: %v", tc.desc, err)
+		}
+
+		data, err := ioutil.ReadAll(obj.Body)
+		obj.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: Unable to read published template: %v", tc.desc, err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%s: Expected a zip to be published: %v", tc.desc, err)
+		}
+
+		found := false
+		for _, f := range zr.File {
+			found = found || f.Name == "base.json"
+		}
+
+		if !found {
+			t.Errorf("%s: Expected the published zip to contain base.json", tc.desc)
+		}
+	}
+}
+
+func TestIsStaged(t *testing.T) {
+	testCases := []struct {
+		key    string
+		staged bool
+	}{
+		{"staging/base.zip", true},
+		{"staging/team/base.tar.gz", true},
+		{"templates/base.zip", false},
+		{"staging", false},
+		{"staging/", false},
+		{"staging/../templates/base.zip", false},
+		{"stagingother/base.zip", false},
+		{"/staging/base.zip", false},
+	}
+
+	for _, tc := range testCases {
+		if got := isStaged(tc.key); got != tc.staged {
+			t.Errorf("Expected %q staged to be %v, got %v", tc.key, tc.staged, got)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	protoValidate "github.com/hailocab/bakery-service/proto/validate"
+
+	"github.com/hailocab/bakery-service/aws"
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/workspace"
+
+	"github.com/hailocab/go-platform-layer/errors"
+	"github.com/hailocab/go-platform-layer/server"
+
+	log "github.com/cihub/seelog"
+	"github.com/hailocab/protobuf/proto"
+	"github.com/nu7hatch/gouuid"
+)
+
+const (
+	// ValidateEndpoint name of endpoint
+	ValidateEndpoint = "com.hailocab.infrastructure.bakery.validate"
+)
+
+// Validate endpoint, checks a template and its variables without
+// running any builds
+func Validate(req *server.Request) (proto.Message, errors.Error) {
+	request := req.Data().(*protoValidate.Request)
+	reqVars := map[string]string{}
+	for _, v := range request.GetVariables() {
+		reqVars[v.GetKey()] = v.GetValue()
+	}
+
+	template := request.GetTemplate()
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, errors.InternalServerError(ValidateEndpoint,
+			fmt.Sprintf("Unable to create ID: %v", err),
+		)
+	}
+
+	dir, err := workspace.Default.Create(id.String())
+	if err != nil {
+		return nil, errors.InternalServerError(ValidateEndpoint, err)
+	}
+
+	defer func() {
+		if err := workspace.Default.Release(id.String(), false); err != nil {
+			log.Errorf("[%s] Unable to release workspace: %v", id.String(), err)
+		}
+	}()
+
+	obj, err := fetchTemplate(template, request.GetFormat(), request.GetVersion())
+	if err != nil {
+		return nil, errors.BadRequest(ValidateEndpoint,
+			fmt.Sprintf("Unable to get object: %v", err),
+		)
+	}
+
+	if _, err := extractTemplate(obj, dir); err != nil {
+		return nil, errors.InternalServerError(ValidateEndpoint, err)
+	}
+
+	rsp := &protoValidate.Response{
+		Valid: proto.Bool(false),
+	}
+
+	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("%s.json", template)))
+	if err != nil {
+		rsp.Errors = append(rsp.Errors, fmt.Sprintf("Bundle doesn't contain %s.json", template))
+		return rsp, nil
+	}
+
+	p, err := packer.New(f, nil)
+	if err != nil {
+		rsp.Errors = append(rsp.Errors, err.Error())
+		return rsp, nil
+	}
+
+	for k := range reqVars {
+		if _, ok := p.Template.Variables[k]; !ok {
+			rsp.UnknownVariables = append(rsp.UnknownVariables, k)
+		}
+	}
+
+	sort.Strings(rsp.UnknownVariables)
+
+	t, err := resolveTarget(request.GetAccountId(), request.GetRegions())
+	if err != nil {
+		return nil, errors.BadRequest(ValidateEndpoint, err.Error())
+	}
+
+	creds, _, err := aws.BuildCredentials(t.Account.ID, sessionName(id.String()))
+	if err != nil {
+		return nil, errors.InternalServerError(ValidateEndpoint, err)
+	}
+
+	vars := packer.ExtractVariables(p.Template.Variables, serviceVariables(dir, t, creds), reqVars)
+
+	v, err := p.Validate(vars)
+	if err != nil {
+		return nil, errors.InternalServerError(ValidateEndpoint, err)
+	}
+
+	rsp.Valid = proto.Bool(v.Valid() && len(rsp.UnknownVariables) == 0)
+	rsp.Errors = v.Errors
+	rsp.MissingVariables = v.MissingVariables
+	rsp.UnknownBuilders = v.UnknownBuilders
+	rsp.UnknownProvisioners = v.UnknownProvisioners
+	rsp.UnknownPostProcessors = v.UnknownPostProcessors
+
+	for _, b := range v.Builds {
+		build := &protoValidate.Build{
+			Name:     proto.String(b.Name),
+			Warnings: b.Warnings,
+		}
+
+		if b.Error != nil {
+			build.Error = proto.String(b.Error.Error())
+		}
+
+		rsp.Builds = append(rsp.Builds, build)
+	}
+
+	return rsp, nil
+}
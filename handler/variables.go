@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hailocab/bakery-service/aws"
+)
+
+var (
+	// automaticVariables are filled in by the service for every build
+	automaticVariables = []string{
+		"cwd",
+		"aws_access_key_id",
+		"aws_secret_access_key",
+		"aws_session_token",
+		"aws_account_id",
+		"aws_region",
+		"aws_regions",
+	}
+)
+
+// target is the account and regions a build bakes into
+type target struct {
+	Account *aws.Account
+	Regions []string
+}
+
+// resolveTarget checks the requested account and regions against the
+// configured accounts. The default account is used if none is requested,
+// and the account's first region if no regions are
+func resolveTarget(accountID string, regions []string) (*target, error) {
+	if len(accountID) == 0 {
+		accountID = aws.DefaultAccount
+	}
+
+	account, err := aws.GetAccount(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regions) == 0 {
+		if len(account.Regions) == 0 {
+			return nil, fmt.Errorf("Account %q has no regions configured", account.ID)
+		}
+
+		regions = account.Regions[:1]
+	}
+
+	seen := map[string]bool{}
+	var _regions []string
+	for _, r := range regions {
+		if !account.HasRegion(r) {
+			return nil, fmt.Errorf("Region %q is not configured for account %q", r, account.ID)
+		}
+
+		if !seen[r] {
+			seen[r] = true
+			_regions = append(_regions, r)
+		}
+	}
+
+	return &target{
+		Account: account,
+		Regions: _regions,
+	}, nil
+}
+
+// serviceVariables returns the values of the automatic variables. The
+// first region is the one packer builds in, the rest are copy targets.
+//
+// Variables can only hold strings, so aws_regions is comma separated.
+// Packer splits comma separated strings when decoding list options, so
+// templates can pass it straight through:
+//
+//	"ami_regions": "{{user `aws_regions`}}"
+//
+// The credential variables are fixed once a build starts. Builders that
+// leave their keys unset read the build's credentials file instead, which
+// is renewed for builds that outlast the session
+func serviceVariables(dir string, t *target, creds map[string]string) map[string]string {
+	return map[string]string{
+		"cwd":                   dir,
+		"aws_access_key_id":     creds["aws_access_key_id"],
+		"aws_secret_access_key": creds["aws_secret_access_key"],
+		"aws_session_token":     creds["aws_session_token"],
+		"aws_account_id":        t.Account.ID,
+		"aws_region":            t.Regions[0],
+		"aws_regions":           strings.Join(t.Regions, ","),
+	}
+}
+
+// sessionName ties the credentials assumed for a build to its ID
+func sessionName(id string) string {
+	return fmt.Sprintf("bakery-%s", id)
+}
+
+func isAutomatic(name string) bool {
+	for _, n := range automaticVariables {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hailocab/bakery-service/elastic"
+	"github.com/hailocab/bakery-service/packer/ui"
+)
+
+// ElasticSource reads logs from elastic search, including those of
+// builds that are still running
+type ElasticSource struct{}
+
+// Read queries elastic search for the messages selected by q
+func (s *ElasticSource) Read(q *Query) ([]*ui.Message, error) {
+	e, err := elastic.NewWithDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create new elastic: %v", err)
+	}
+
+	docs, err := e.Messages(q.ID, q.Builder, q.Types, q.Since, q.Offset, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]*ui.Message, 0, len(docs))
+	for _, d := range docs {
+		if d == nil {
+			continue
+		}
+
+		var msg ui.Message
+		if err := json.Unmarshal(*d, &msg); err != nil {
+			return nil, fmt.Errorf("Unable to read message: %v", err)
+		}
+
+		if q.matches(&msg) {
+			msgs = append(msgs, &msg)
+		}
+	}
+
+	return msgs, nil
+}
@@ -0,0 +1,123 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hailocab/bakery-service/packer/ui"
+	"github.com/hailocab/bakery-service/storage"
+
+	"github.com/hailocab/go-service-layer/config"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	// Path is where build logs are archived in storage
+	Path = "logs"
+
+	// DefaultLimit is how many messages are read if no limit is set
+	DefaultLimit = 100
+
+	// MaxLimit is the most messages read at once
+	MaxLimit = 1000
+)
+
+var (
+	// Default source logs are read from
+	Default Source
+
+	// ErrNotFound is returned for builds without a log
+	ErrNotFound = fmt.Errorf("Log not found")
+)
+
+// Source reads the messages of build logs
+type Source interface {
+	Read(q *Query) ([]*ui.Message, error)
+}
+
+// Query selects messages of a build
+type Query struct {
+	ID string
+
+	// Types names the message types to return, all if empty
+	Types []string
+
+	// Builder only returns the messages of a builder, if set
+	Builder string
+
+	// Since only returns messages sequenced after it, if set. Messages
+	// can share a date, but never a sequence
+	Since uint64
+
+	Offset int
+	Limit  int
+}
+
+// Key returns the storage key of a build's log
+func Key(id string) string {
+	return fmt.Sprintf("%s/%s.log", Path, id)
+}
+
+// matches reports whether msg is selected by the query
+func (q *Query) matches(msg *ui.Message) bool {
+	if q.Since > 0 && msg.Sequence <= q.Since {
+		return false
+	}
+
+	if len(q.Builder) > 0 && q.Builder != msg.Builder {
+		return false
+	}
+
+	if len(q.Types) == 0 {
+		return true
+	}
+
+	for _, t := range q.Types {
+		if strings.EqualFold(t, msg.Type.String()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Init loads config and creates the default source
+func Init() {
+	conf, err := loadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	switch conf.Source {
+	case "elastic":
+		Default = &ElasticSource{}
+	case "storage":
+		Default = NewStorageSource(storage.Default)
+	default:
+		panic(fmt.Errorf("Unknown log source %q", conf.Source))
+	}
+}
+
+func loadConfig() (*logsConfig, error) {
+	configJSON := config.AtPath(
+		"hailo", "service", "bakery", "logs",
+	).AsJson()
+
+	log.Debugf("Logs Config: %v", string(configJSON))
+
+	conf := logsConfig{
+		Source: "elastic",
+	}
+
+	if err := json.Unmarshal(configJSON, &conf); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+type logsConfig struct {
+	Source string `json:"source"`
+}
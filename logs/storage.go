@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hailocab/bakery-service/packer/ui"
+	"github.com/hailocab/bakery-service/storage"
+)
+
+// StorageSource reads the logs archived to storage. Logs are only
+// archived once builds end
+type StorageSource struct {
+	Store storage.Store
+}
+
+// NewStorageSource creates a source reading from store
+func NewStorageSource(store storage.Store) *StorageSource {
+	return &StorageSource{
+		Store: store,
+	}
+}
+
+// Read scans a build's archived log for the messages selected by q
+func (s *StorageSource) Read(q *Query) ([]*ui.Message, error) {
+	obj, err := s.Store.Get(Key(q.ID), "")
+	if err == storage.ErrNotFound {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer obj.Body.Close()
+
+	var (
+		msgs    []*ui.Message
+		skipped int
+	)
+
+	r := bufio.NewReader(obj.Body)
+	for len(msgs) < q.Limit {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var msg ui.Message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				return nil, fmt.Errorf("Unable to read log %q: %v", obj.Key, err)
+			}
+
+			if q.matches(&msg) {
+				if skipped < q.Offset {
+					skipped++
+				} else {
+					msgs = append(msgs, &msg)
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read log %q: %v", obj.Key, err)
+		}
+	}
+
+	return msgs, nil
+}
@@ -0,0 +1,131 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hailocab/bakery-service/storage"
+)
+
+func TestStorageSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs")
+	if err != nil {
+		t.Fatalf("Unable to create root: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store, err := storage.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+
+	lines := []string{
+		`{"Date":"2016-01-01T00:00:01Z","ID":"abc","Sequence":1,"Message":"one","Type":"Say"}`,
+		`{"Date":"2016-01-01T00:00:02Z","ID":"abc","Sequence":2,"Builder":"docker","Message":"two","Type":"Error"}`,
+		`{"Date":"2016-01-01T00:00:03Z","ID":"abc","Sequence":3,"Message":"three","Type":"Say"}`,
+		`{"Date":"2016-01-01T00:00:04Z","ID":"abc","Sequence":4,"Message":"four","Type":"Message"}`,
+	}
+
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.WriteString(l + "\n")
+	}
+
+	if _, err := store.Put(Key("abc"), bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Unable to put log: %v", err)
+	}
+
+	src := NewStorageSource(store)
+
+	testCases := []struct {
+		query    Query
+		expected []string
+	}{
+		{Query{ID: "abc", Limit: 10}, []string{"one", "two", "three", "four"}},
+		{Query{ID: "abc", Offset: 1, Limit: 2}, []string{"two", "three"}},
+		{Query{ID: "abc", Types: []string{"say"}, Limit: 10}, []string{"one", "three"}},
+		{Query{ID: "abc", Types: []string{"Say"}, Offset: 1, Limit: 10}, []string{"three"}},
+		{Query{ID: "abc", Since: 2, Limit: 10}, []string{"three", "four"}},
+		{Query{ID: "abc", Builder: "docker", Limit: 10}, []string{"two"}},
+	}
+
+	for i, tc := range testCases {
+		msgs, err := src.Read(&tc.query)
+		if err != nil {
+			t.Fatalf("%d: Unable to read: %v", i, err)
+		}
+
+		var got []string
+		for _, m := range msgs {
+			got = append(got, m.Message)
+		}
+
+		if a, b := mustJSON(got), mustJSON(tc.expected); a != b {
+			t.Errorf("%d: Expected %s, got %s", i, b, a)
+		}
+	}
+
+	if _, err := src.Read(&Query{ID: "missing", Limit: 10}); err != ErrNotFound {
+		t.Errorf("Expected a missing log to be not found, got %v", err)
+	}
+}
+
+func mustJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func TestStorageSourceSharedDate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs")
+	if err != nil {
+		t.Fatalf("Unable to create root: %v", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store, err := storage.NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+
+	// Builders writing at once stamp the same date, and dates can be out
+	// of sequence
+	log := `{"Date":"2016-01-01T00:00:01Z","ID":"abc","Sequence":1,"Builder":"docker","Message":"one","Type":"Say"}
+{"Date":"2016-01-01T00:00:01Z","ID":"abc","Sequence":2,"Builder":"amazon-ebs","Message":"two","Type":"Say"}
+{"Date":"2016-01-01T00:00:00Z","ID":"abc","Sequence":3,"Builder":"docker","Message":"three","Type":"Say"}
+`
+
+	if _, err := store.Put(Key("abc"), bytes.NewReader([]byte(log))); err != nil {
+		t.Fatalf("Unable to put log: %v", err)
+	}
+
+	src := NewStorageSource(store)
+
+	// Tail the log a message at a time, as a CLI would
+	var (
+		got    []string
+		cursor uint64
+	)
+
+	for i := 0; i < 5; i++ {
+		msgs, err := src.Read(&Query{ID: "abc", Since: cursor, Limit: 1})
+		if err != nil {
+			t.Fatalf("Unable to read: %v", err)
+		}
+
+		if len(msgs) == 0 {
+			break
+		}
+
+		got = append(got, msgs[0].Message)
+		cursor = msgs[0].Sequence
+	}
+
+	if a, b := mustJSON(got), mustJSON([]string{"one", "two", "three"}); a != b {
+		t.Errorf("Expected %s, got %s", b, a)
+	}
+}
@@ -3,11 +3,25 @@ package main
 import (
 	"time"
 
+	protoArtifacts "github.com/hailocab/bakery-service/proto/artifacts"
 	protoBuild "github.com/hailocab/bakery-service/proto/build"
+	protoCancel "github.com/hailocab/bakery-service/proto/cancel"
+	protoLogs "github.com/hailocab/bakery-service/proto/logs"
+	protoStatus "github.com/hailocab/bakery-service/proto/status"
+	protoTemplates "github.com/hailocab/bakery-service/proto/templates"
+	protoUpload "github.com/hailocab/bakery-service/proto/upload"
+	protoValidate "github.com/hailocab/bakery-service/proto/validate"
 
 	"github.com/hailocab/bakery-service/aws"
 	"github.com/hailocab/bakery-service/elastic"
+	"github.com/hailocab/bakery-service/events"
 	"github.com/hailocab/bakery-service/handler"
+	"github.com/hailocab/bakery-service/logs"
+	"github.com/hailocab/bakery-service/packer"
+	"github.com/hailocab/bakery-service/queue"
+	"github.com/hailocab/bakery-service/registry"
+	"github.com/hailocab/bakery-service/storage"
+	"github.com/hailocab/bakery-service/workspace"
 
 	log "github.com/cihub/seelog"
 	service "github.com/hailocab/go-platform-layer/server"
@@ -36,10 +50,97 @@ func main() {
 		Upper95:          100,
 	})
 
+	service.Register(&service.Endpoint{
+		Authoriser:       service.RoleAuthoriser([]string{"ADMIN", "PLATFORM"}),
+		Handler:          handler.Status,
+		Mean:             50,
+		Name:             "status",
+		RequestProtocol:  new(protoStatus.Request),
+		ResponseProtocol: new(protoStatus.Response),
+		Upper95:          100,
+	})
+
+	service.Register(&service.Endpoint{
+		Authoriser:       service.RoleAuthoriser([]string{"ADMIN", "PLATFORM"}),
+		Handler:          handler.Cancel,
+		Mean:             5000,
+		Name:             "cancel",
+		RequestProtocol:  new(protoCancel.Request),
+		ResponseProtocol: new(protoCancel.Response),
+		Upper95:          60000,
+	})
+
+	service.Register(&service.Endpoint{
+		Authoriser:       service.RoleAuthoriser([]string{"ADMIN", "PLATFORM"}),
+		Handler:          handler.Artifacts,
+		Mean:             50,
+		Name:             "artifacts",
+		RequestProtocol:  new(protoArtifacts.Request),
+		ResponseProtocol: new(protoArtifacts.Response),
+		Upper95:          100,
+	})
+
+	service.Register(&service.Endpoint{
+		Authoriser:       service.RoleAuthoriser([]string{"ADMIN", "PLATFORM"}),
+		Handler:          handler.TemplatesList,
+		Mean:             200,
+		Name:             "templates.list",
+		RequestProtocol:  new(protoTemplates.ListRequest),
+		ResponseProtocol: new(protoTemplates.ListResponse),
+		Upper95:          500,
+	})
+
+	service.Register(&service.Endpoint{
+		Authoriser:       service.RoleAuthoriser([]string{"ADMIN", "PLATFORM"}),
+		Handler:          handler.TemplatesDescribe,
+		Mean:             500,
+		Name:             "templates.describe",
+		RequestProtocol:  new(protoTemplates.DescribeRequest),
+		ResponseProtocol: new(protoTemplates.DescribeResponse),
+		Upper95:          2000,
+	})
+
+	service.Register(&service.Endpoint{
+		Authoriser:       service.RoleAuthoriser([]string{"ADMIN", "PLATFORM"}),
+		Handler:          handler.Validate,
+		Mean:             1000,
+		Name:             "validate",
+		RequestProtocol:  new(protoValidate.Request),
+		ResponseProtocol: new(protoValidate.Response),
+		Upper95:          5000,
+	})
+
+	service.Register(&service.Endpoint{
+		Authoriser:       service.RoleAuthoriser([]string{"ADMIN", "PLATFORM"}),
+		Handler:          handler.Upload,
+		Mean:             1000,
+		Name:             "upload",
+		RequestProtocol:  new(protoUpload.Request),
+		ResponseProtocol: new(protoUpload.Response),
+		Upper95:          5000,
+	})
+
+	service.Register(&service.Endpoint{
+		Authoriser:       service.RoleAuthoriser([]string{"ADMIN", "PLATFORM"}),
+		Handler:          handler.Logs,
+		Mean:             500,
+		Name:             "logs",
+		RequestProtocol:  new(protoLogs.Request),
+		ResponseProtocol: new(protoLogs.Response),
+		Upper95:          2000,
+	})
+
 	config.WaitUntilLoaded(time.Second * 2)
 
 	aws.Init()
+	storage.Init()
 	elastic.Init()
+	logs.Init()
+	events.Init()
+	packer.Init()
+	registry.Init()
+	queue.Init()
+	workspace.Init()
 
 	service.Run()
 }
@@ -1,46 +1,473 @@
 package packer
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/hailocab/go-service-layer/config"
+
+	log "github.com/cihub/seelog"
 )
 
+const (
+	// DefaultMaxArchiveSize max number of bytes an archive may extract to
+	DefaultMaxArchiveSize = 512 * 1024 * 1024
+
+	// DefaultMaxArchiveFiles max number of entries in an archive
+	DefaultMaxArchiveFiles = 10000
+)
+
+var (
+	// ArchiveLimits used when extracting template bundles
+	ArchiveLimits = Limits{
+		MaxSize:  DefaultMaxArchiveSize,
+		MaxFiles: DefaultMaxArchiveFiles,
+	}
+)
+
+// Limits bounds what an archive may extract, zero means unlimited
+type Limits struct {
+	MaxSize  int64 `json:"maxSize"`
+	MaxFiles int   `json:"maxFiles"`
+}
+
+// UnzipReader extracts the zip archive in r to dst
 func UnzipReader(r io.Reader, dst string) error {
-	archive := filepath.Join(dst, "archive.zip")
-	arcF, err := os.Create(archive)
+	return UnzipReaderWithLimits(r, dst, ArchiveLimits)
+}
+
+// UnzipReaderWithLimits extracts the zip archive in r to dst. Entries
+// must stay within dst, symlinks included
+func UnzipReaderWithLimits(r io.Reader, dst string, limits Limits) error {
+	// zip needs random access, so spool it to disk first
+	arcF, err := ioutil.TempFile("", "bakery-archive")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(arcF.Name())
+	defer arcF.Close()
+
+	size, err := io.Copy(arcF, r)
 	if err != nil {
 		return err
 	}
 
-	if _, err := io.Copy(arcF, r); err != nil {
+	zipR, err := zip.NewReader(arcF, size)
+	if err != nil {
 		return err
 	}
 
-	zipR, err := zip.OpenReader(archive)
+	e, err := newExtractor(dst, limits)
 	if err != nil {
 		return err
 	}
 
 	for _, f := range zipR.File {
-		srcF, err := f.Open()
+		if err := e.zipEntry(f); err != nil {
+			return err
+		}
+	}
+
+	return e.finish()
+}
+
+func (e *extractor) zipEntry(f *zip.File) error {
+	mode := f.Mode()
+
+	switch {
+	case mode.IsDir():
+		return e.dir(f.Name, mode)
+	case mode&os.ModeSymlink != 0:
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		target, err := ioutil.ReadAll(io.LimitReader(rc, 4096))
+		if err != nil {
+			return err
+		}
+
+		return e.symlink(f.Name, string(target))
+	case mode.IsRegular():
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		return e.file(f.Name, mode, rc)
+	default:
+		return fmt.Errorf("Unsupported archive entry %q: %v", f.Name, mode)
+	}
+}
+
+// ZipDir writes the contents of dir to w as a zip archive, keeping
+// file modes and symlinks
+func ZipDir(dir string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		dstF, err := os.Create(filepath.Join(dst, f.Name))
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		fh, err := zip.FileInfoHeader(info)
 		if err != nil {
-			srcF.Close()
 			return err
 		}
-		_, err = io.Copy(dstF, srcF)
-		srcF.Close()
-		dstF.Close()
+
+		fh.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			fh.Name += "/"
+		} else {
+			fh.Method = zip.Deflate
+		}
+
+		fw, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			_, err = io.WriteString(fw, target)
+			return err
+		case info.Mode().IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			_, err = io.Copy(fw, f)
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("Unable to zip %s: %v", dir, err)
+	}
+
+	return zw.Close()
+}
+
+// UntarReader extracts the tar archive in r to dst
+func UntarReader(r io.Reader, dst string, limits Limits) error {
+	e, err := newExtractor(dst, limits)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("Unable to read tar archive: %v", err)
+		}
+
+		if err := e.tarEntry(hdr, tr); err != nil {
+			return err
+		}
+	}
+
+	return e.finish()
+}
+
+// UntarGzipReader extracts the gzipped tar archive in r to dst
+func UntarGzipReader(r io.Reader, dst string, limits Limits) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("Unable to read gzip archive: %v", err)
+	}
+	defer gr.Close()
+
+	return UntarReader(gr, dst, limits)
+}
+
+// UntarBzip2Reader extracts the bzip2 compressed tar archive in r to dst
+func UntarBzip2Reader(r io.Reader, dst string, limits Limits) error {
+	return UntarReader(bzip2.NewReader(r), dst, limits)
+}
+
+func (e *extractor) tarEntry(hdr *tar.Header, r io.Reader) error {
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return e.dir(hdr.Name, hdr.FileInfo().Mode())
+	case tar.TypeReg, tar.TypeRegA:
+		return e.file(hdr.Name, hdr.FileInfo().Mode(), r)
+	case tar.TypeSymlink:
+		return e.symlink(hdr.Name, hdr.Linkname)
+	case tar.TypeLink:
+		// Hard links are extracted as copies of the file they link to
+		src, err := e.path(hdr.Linkname)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(src)
+		if err != nil {
+			return fmt.Errorf("Unable to extract hard link %q: %v", hdr.Name, err)
+		}
+		defer f.Close()
+
+		return e.file(hdr.Name, hdr.FileInfo().Mode(), f)
+	case tar.TypeXGlobalHeader:
+		return nil
+	default:
+		return fmt.Errorf("Unsupported archive entry %q: %c", hdr.Name, hdr.Typeflag)
+	}
+}
+
+type link struct {
+	path   string
+	target string
+}
+
+// extractor writes archive entries below dst, enforcing limits
+type extractor struct {
+	dst    string
+	limits Limits
+
+	files int
+	size  int64
+	links []link
+}
+
+func newExtractor(dst string, limits Limits) (*extractor, error) {
+	abs, err := filepath.Abs(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve dst itself so symlinks can be checked against it
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extractor{
+		dst:    real,
+		limits: limits,
+	}, nil
+}
+
+// path returns where name should be extracted, or an error if that is
+// outside of dst
+func (e *extractor) path(name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("Archive entry %q has an absolute path", name)
+	}
+
+	p := filepath.Join(e.dst, name)
+	if !e.contains(p) {
+		return "", fmt.Errorf("Archive entry %q is outside of the destination", name)
+	}
+
+	return p, nil
+}
+
+func (e *extractor) contains(p string) bool {
+	return p == e.dst || strings.HasPrefix(p, e.dst+string(os.PathSeparator))
+}
+
+func (e *extractor) count(name string) error {
+	e.files++
+	if e.limits.MaxFiles > 0 && e.files > e.limits.MaxFiles {
+		return fmt.Errorf("Archive has more than %d entries", e.limits.MaxFiles)
+	}
+
+	return nil
+}
+
+func (e *extractor) dir(name string, mode os.FileMode) error {
+	if err := e.count(name); err != nil {
+		return err
+	}
+
+	p, err := e.path(name)
+	if err != nil {
+		return err
+	}
+
+	// Always keep directories writable by us, or nothing goes in them
+	return os.MkdirAll(p, mode.Perm()|0700)
+}
+
+func (e *extractor) file(name string, mode os.FileMode, r io.Reader) error {
+	if err := e.count(name); err != nil {
+		return err
+	}
+
+	p, err := e.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if e.limits.MaxSize > 0 {
+		r = io.LimitReader(r, e.limits.MaxSize-e.size+1)
+	}
+
+	n, err := io.Copy(f, r)
+	e.size += n
+	if err != nil {
+		return fmt.Errorf("Unable to extract %q: %v", name, err)
+	}
+
+	if e.limits.MaxSize > 0 && e.size > e.limits.MaxSize {
+		return fmt.Errorf("Archive extracts to more than %d bytes", e.limits.MaxSize)
+	}
+
+	// Permissions passed to OpenFile are subject to the umask
+	return os.Chmod(p, perm)
+}
+
+// symlink records a link to be created once every other entry has been
+// written, so nothing is ever extracted through a link
+func (e *extractor) symlink(name string, target string) error {
+	if err := e.count(name); err != nil {
+		return err
+	}
+
+	p, err := e.path(name)
+	if err != nil {
+		return err
+	}
+
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("Symlink %q has an absolute target %q", name, target)
+	}
+
+	if !e.contains(filepath.Join(filepath.Dir(p), target)) {
+		return fmt.Errorf("Symlink %q points outside of the destination", name)
+	}
+
+	e.links = append(e.links, link{p, target})
+
+	return nil
+}
+
+// finish creates the symlinks and checks that each one, fully
+// resolved, still points within dst
+func (e *extractor) finish() error {
+	for _, l := range e.links {
+		// Links can't be nested in other links, which may point anywhere
+		// until they have all been resolved
+		if err := e.checkParents(l.path); err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+			return err
+		}
+
+		if err := os.Symlink(l.target, l.path); err != nil {
+			return err
+		}
+	}
+
+	for _, l := range e.links {
+		real, err := filepath.EvalSymlinks(l.path)
+		if err != nil || !e.contains(real) {
+			os.Remove(l.path)
+			return fmt.Errorf("Symlink %q doesn't resolve within the destination", l.path)
+		}
+	}
+
+	return nil
+}
+
+func (e *extractor) checkParents(p string) error {
+	rel, err := filepath.Rel(e.dst, filepath.Dir(p))
+	if err != nil {
+		return err
+	}
+
+	cur := e.dst
+	for _, part := range strings.Split(rel, string(os.PathSeparator)) {
+		cur = filepath.Join(cur, part)
+
+		fi, err := os.Lstat(cur)
+		if os.IsNotExist(err) {
+			return nil
+		}
+
 		if err != nil {
 			return err
 		}
+
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("Symlink %q is inside another symlink", p)
+		}
 	}
 
 	return nil
 }
+
+// Init loads the archive limits from config
+func Init() {
+	limits, err := loadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	ArchiveLimits = *limits
+}
+
+func loadConfig() (*Limits, error) {
+	configJSON := config.AtPath(
+		"hailo", "service", "bakery", "archive",
+	).AsJson()
+
+	log.Debugf("Archive Config: %v", string(configJSON))
+
+	limits := ArchiveLimits
+	if err := json.Unmarshal(configJSON, &limits); err != nil {
+		return nil, err
+	}
+
+	return &limits, nil
+}
@@ -0,0 +1,213 @@
+package packer
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testEntry struct {
+	name string
+	mode os.FileMode
+	body string
+}
+
+func buildZip(t *testing.T, entries []testEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e.name}
+		fh.SetMode(e.mode)
+
+		f, err := w.CreateHeader(fh)
+		if err != nil {
+			t.Fatalf("Unable to create %q: %v", e.name, err)
+		}
+
+		f.Write([]byte(e.body))
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unable to write zip: %v", err)
+	}
+
+	return &buf
+}
+
+func TestUnzipReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+		limits  Limits
+		err     string
+		check   func(t *testing.T, dst string)
+	}{
+		{
+			name: "nested files without directory entries",
+			entries: []testEntry{
+				{"base.json", 0644, "{}"},
+				{"scripts/nested/setup.sh", 0755, "#!/bin/sh"},
+			},
+			check: func(t *testing.T, dst string) {
+				fi, err := os.Stat(filepath.Join(dst, "scripts/nested/setup.sh"))
+				if err != nil {
+					t.Fatalf("Nested file not extracted: %v", err)
+				}
+
+				if fi.Mode().Perm() != 0755 {
+					t.Fatalf("Executable bit lost: %v", fi.Mode())
+				}
+			},
+		},
+		{
+			name: "directory entries",
+			entries: []testEntry{
+				{"scripts/", os.ModeDir | 0755, ""},
+				{"scripts/setup.sh", 0644, "echo"},
+			},
+			check: func(t *testing.T, dst string) {
+				data, err := ioutil.ReadFile(filepath.Join(dst, "scripts/setup.sh"))
+				if err != nil || string(data) != "echo" {
+					t.Fatalf("File not extracted: %v", err)
+				}
+			},
+		},
+		{
+			name: "symlink within destination",
+			entries: []testEntry{
+				{"scripts/setup.sh", 0755, "echo"},
+				{"setup.sh", os.ModeSymlink | 0777, "scripts/setup.sh"},
+			},
+			check: func(t *testing.T, dst string) {
+				data, err := ioutil.ReadFile(filepath.Join(dst, "setup.sh"))
+				if err != nil || string(data) != "echo" {
+					t.Fatalf("Symlink not extracted: %v", err)
+				}
+			},
+		},
+		{
+			name:    "parent traversal",
+			entries: []testEntry{{"../evil.sh", 0644, "boom"}},
+			err:     "outside of the destination",
+		},
+		{
+			name:    "nested traversal",
+			entries: []testEntry{{"scripts/../../evil.sh", 0644, "boom"}},
+			err:     "outside of the destination",
+		},
+		{
+			name:    "absolute path",
+			entries: []testEntry{{"/tmp/evil.sh", 0644, "boom"}},
+			err:     "absolute path",
+		},
+		{
+			name:    "symlink outside destination",
+			entries: []testEntry{{"etc", os.ModeSymlink | 0777, "../../etc"}},
+			err:     "outside of the destination",
+		},
+		{
+			name:    "absolute symlink",
+			entries: []testEntry{{"etc", os.ModeSymlink | 0777, "/etc"}},
+			err:     "absolute target",
+		},
+		{
+			name: "chained symlinks escaping",
+			entries: []testEntry{
+				{"a/b/c", os.ModeSymlink | 0777, "../.."},
+				{"escape", os.ModeSymlink | 0777, "a/b/c/../../.."},
+			},
+			err: "doesn't resolve within",
+		},
+		{
+			name: "symlink nested in symlink",
+			entries: []testEntry{
+				{"a/b/c", os.ModeSymlink | 0777, "../.."},
+				{"a/b/c/d", os.ModeSymlink | 0777, "."},
+			},
+			err: "inside another symlink",
+		},
+		{
+			name: "too many files",
+			entries: []testEntry{
+				{"a", 0644, ""},
+				{"b", 0644, ""},
+				{"c", 0644, ""},
+			},
+			limits: Limits{MaxFiles: 2},
+			err:    "more than 2 entries",
+		},
+		{
+			name: "too large",
+			entries: []testEntry{
+				{"a", 0644, "12345"},
+				{"b", 0644, "67890"},
+			},
+			limits: Limits{MaxSize: 8},
+			err:    "more than 8 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		dst, err := ioutil.TempDir("", "bakery-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = UnzipReaderWithLimits(buildZip(t, tt.entries), dst, tt.limits)
+
+		switch {
+		case len(tt.err) == 0 && err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		case len(tt.err) > 0 && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.err, err)
+		case tt.check != nil:
+			tt.check(t, dst)
+		}
+
+		os.RemoveAll(dst)
+	}
+}
+
+func TestZipDir(t *testing.T) {
+	src, err := ioutil.TempDir("", "bakery-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+
+	if err := UnzipReaderWithLimits(buildZip(t, []testEntry{
+		{"base.json", 0644, "{}"},
+		{"scripts/setup.sh", 0755, "#!/bin/sh"},
+		{"setup.sh", os.ModeSymlink | 0777, "scripts/setup.sh"},
+	}), src, Limits{}); err != nil {
+		t.Fatalf("Unable to extract: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ZipDir(src, &buf); err != nil {
+		t.Fatalf("Unable to zip: %v", err)
+	}
+
+	dst, err := ioutil.TempDir("", "bakery-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	if err := UnzipReaderWithLimits(&buf, dst, Limits{}); err != nil {
+		t.Fatalf("Unable to extract zipped dir: %v", err)
+	}
+
+	if fi, err := os.Stat(filepath.Join(dst, "scripts/setup.sh")); err != nil || fi.Mode().Perm() != 0755 {
+		t.Fatalf("Script not kept: %v", err)
+	}
+
+	if target, err := os.Readlink(filepath.Join(dst, "setup.sh")); err != nil || target != "scripts/setup.sh" {
+		t.Fatalf("Symlink not kept: %q %v", target, err)
+	}
+}
@@ -0,0 +1,34 @@
+package packer
+
+import (
+	"strings"
+)
+
+// AMI is an image produced in a single region
+type AMI struct {
+	Region string
+	ID     string
+}
+
+// ParseAMIs splits the ID of an amazon builder artifact, formatted as
+// "region:ami-id,region:ami-id", into its images. ok is false if id
+// isn't in that format
+func ParseAMIs(id string) (amis []AMI, ok bool) {
+	if len(id) == 0 {
+		return nil, false
+	}
+
+	for _, part := range strings.Split(id, ",") {
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 || !strings.HasPrefix(kv[1], "ami-") {
+			return nil, false
+		}
+
+		amis = append(amis, AMI{
+			Region: kv[0],
+			ID:     kv[1],
+		})
+	}
+
+	return amis, true
+}
@@ -0,0 +1,27 @@
+package packer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAMIs(t *testing.T) {
+	tests := []struct {
+		id   string
+		amis []AMI
+		ok   bool
+	}{
+		{"eu-west-1:ami-123", []AMI{{"eu-west-1", "ami-123"}}, true},
+		{"eu-west-1:ami-123,us-east-1:ami-456", []AMI{{"eu-west-1", "ami-123"}, {"us-east-1", "ami-456"}}, true},
+		{"/tmp/output/image.box", nil, false},
+		{"sha256:abcdef", nil, false},
+		{"", nil, false},
+	}
+
+	for _, tt := range tests {
+		amis, ok := ParseAMIs(tt.id)
+		if ok != tt.ok || !reflect.DeepEqual(amis, tt.amis) {
+			t.Errorf("ParseAMIs(%q) = %v, %v; want %v, %v", tt.id, amis, ok, tt.amis, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package packer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/packer/packer"
+)
+
+type fakeArtifact struct {
+	id string
+}
+
+func (a *fakeArtifact) BuilderId() string             { return "bakery.fake" }
+func (a *fakeArtifact) Files() []string               { return nil }
+func (a *fakeArtifact) Id() string                    { return a.id }
+func (a *fakeArtifact) String() string                { return "Fake artifact: " + a.id }
+func (a *fakeArtifact) State(name string) interface{} { return nil }
+func (a *fakeArtifact) Destroy() error                { return nil }
+
+type fakeBuild struct {
+	name       string
+	prepareErr error
+	runErr     error
+	panics     bool
+	warnings   []string
+
+	// block makes Run wait until the build is cancelled
+	block     bool
+	cancelled chan struct{}
+	once      sync.Once
+}
+
+func newFakeBuild(name string) *fakeBuild {
+	return &fakeBuild{
+		name:      name,
+		cancelled: make(chan struct{}),
+	}
+}
+
+func (b *fakeBuild) Name() string { return b.name }
+
+func (b *fakeBuild) Prepare() ([]string, error) {
+	return b.warnings, b.prepareErr
+}
+
+func (b *fakeBuild) Run(ui packer.Ui, cache packer.Cache) ([]packer.Artifact, error) {
+	if b.block {
+		<-b.cancelled
+		return nil, fmt.Errorf("Build was cancelled")
+	}
+
+	if b.panics {
+		panic("builder crashed")
+	}
+
+	if b.runErr != nil {
+		return nil, b.runErr
+	}
+
+	if ui != nil {
+		ui.Say("Building " + b.name)
+	}
+
+	return []packer.Artifact{&fakeArtifact{id: b.name + "-1"}}, nil
+}
+
+func (b *fakeBuild) Cancel() {
+	b.once.Do(func() {
+		close(b.cancelled)
+	})
+}
+
+func (b *fakeBuild) SetDebug(bool) {}
+func (b *fakeBuild) SetForce(bool) {}
+
+// fakeUi records which builder each message came from
+type fakeUi struct {
+	packer.Ui
+
+	builder string
+	lock    *sync.Mutex
+	said    map[string]string
+	machine map[string][]string
+}
+
+func newFakeUi() *fakeUi {
+	return &fakeUi{
+		lock:    &sync.Mutex{},
+		said:    map[string]string{},
+		machine: map[string][]string{},
+	}
+}
+
+func (u *fakeUi) ForBuilder(name string) packer.Ui {
+	return &fakeUi{builder: name, lock: u.lock, said: u.said, machine: u.machine}
+}
+
+func (u *fakeUi) Machine(t string, args ...string) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.machine[u.builder] = append(u.machine[u.builder], strings.Join(append([]string{t}, args...), ","))
+}
+
+func (u *fakeUi) Say(message string) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.said[u.builder] = message
+}
+
+func newTestPacker() *Packer {
+	return &Packer{
+		running: map[string]packer.Build{},
+	}
+}
+
+func TestProcessBuilds(t *testing.T) {
+	var builds []packer.Build
+	for i := 0; i < 20; i++ {
+		b := newFakeBuild(fmt.Sprintf("build-%d", i))
+
+		switch i % 3 {
+		case 1:
+			b.prepareErr = fmt.Errorf("prepare failed")
+		case 2:
+			b.runErr = fmt.Errorf("run failed")
+			b.warnings = []string{"careful"}
+		}
+
+		builds = append(builds, b)
+	}
+
+	p := newTestPacker()
+	results := p.ProcessBuilds(builds)
+
+	if len(results) != len(builds) {
+		t.Fatalf("Expected %d results, got %d", len(builds), len(results))
+	}
+
+	for i, r := range results {
+		if r.Name != builds[i].Name() {
+			t.Fatalf("Result %d is for %q, expected %q", i, r.Name, builds[i].Name())
+		}
+
+		switch i % 3 {
+		case 0:
+			if r.Error != nil || len(r.Artifacts) != 1 {
+				t.Fatalf("Expected %q to succeed: %#v", r.Name, r)
+			}
+		case 1, 2:
+			if r.Error == nil || len(r.Artifacts) != 0 {
+				t.Fatalf("Expected %q to fail: %#v", r.Name, r)
+			}
+		}
+
+		if i%3 == 2 && len(r.Warnings) != 1 {
+			t.Fatalf("Warnings not recorded for %q", r.Name)
+		}
+	}
+
+	if errs := results.Errors(); len(errs) != 13 {
+		t.Fatalf("Expected 13 errors, got %d", len(errs))
+	}
+
+	if artifacts := results.Artifacts(); len(artifacts) != 7 {
+		t.Fatalf("Expected 7 builds with artifacts, got %d", len(artifacts))
+	}
+}
+
+func TestBuilderUi(t *testing.T) {
+	ui := newFakeUi()
+
+	p := newTestPacker()
+	p.ui = ui
+	p.ProcessBuilds([]packer.Build{newFakeBuild("one"), newFakeBuild("two")})
+
+	for _, n := range []string{"one", "two"} {
+		if ui.said[n] != "Building "+n {
+			t.Errorf("Expected output of %q to be tagged, got %v", n, ui.said)
+		}
+	}
+}
+
+func TestMachineResult(t *testing.T) {
+	ui := newFakeUi()
+
+	failed := newFakeBuild("failed")
+	failed.runErr = fmt.Errorf("run failed")
+
+	p := newTestPacker()
+	p.ui = ui
+	p.ProcessBuilds([]packer.Build{newFakeBuild("ok"), failed})
+
+	expected := map[string]string{
+		"ok": strings.Join([]string{
+			"artifact-count,1",
+			"artifact,0,builder-id,bakery.fake",
+			"artifact,0,id,ok-1",
+			"artifact,0,string,Fake artifact: ok-1",
+			"artifact,0,files-count,0",
+			"artifact,0,end",
+		}, "\n"),
+		"failed": "error,run failed",
+		"":       "error-count,1",
+	}
+
+	for n, e := range expected {
+		if got := strings.Join(ui.machine[n], "\n"); got != e {
+			t.Errorf("Unexpected machine output for %q:\n%s", n, got)
+		}
+	}
+}
+
+func TestProcessBuildsPanic(t *testing.T) {
+	ui := newFakeUi()
+
+	b := newFakeBuild("crashed")
+	b.panics = true
+
+	p := newTestPacker()
+	p.ui = ui
+	results := p.ProcessBuilds([]packer.Build{b, newFakeBuild("ok")})
+
+	if results[0].Error == nil || results[1].Error != nil {
+		t.Fatalf("Expected only the crashed build to fail, got %v", results.Errors())
+	}
+
+	// The crashed build's result is still written, so it's tracked
+	if got := strings.Join(ui.machine["crashed"], "\n"); !strings.HasPrefix(got, "error,") {
+		t.Errorf("Expected the crashed build to report its error, got %q", got)
+	}
+
+	if len(p.running) != 0 {
+		t.Errorf("Expected no builds to be left running, got %v", p.running)
+	}
+}
+
+func TestCancelBuilds(t *testing.T) {
+	p := newTestPacker()
+
+	b := newFakeBuild("blocking")
+	b.block = true
+
+	done := make(chan BuildResults)
+	go func() {
+		done <- p.ProcessBuilds([]packer.Build{b})
+	}()
+
+	// Wait for the build to start running
+	for {
+		p.lock.Lock()
+		n := len(p.running)
+		p.lock.Unlock()
+
+		if n > 0 {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	p.Cancel()
+
+	results := <-done
+	if results[0].Error == nil {
+		t.Fatal("Cancelled build didn't error")
+	}
+
+	// Builds processed after cancelling never run
+	results = p.ProcessBuilds([]packer.Build{newFakeBuild("late")})
+	if results[0].Error != ErrCancelled {
+		t.Fatalf("Expected ErrCancelled, got %v", results[0].Error)
+	}
+}
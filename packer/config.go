@@ -2,6 +2,7 @@ package packer
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -16,6 +17,9 @@ type Config struct {
 	PluginMinPort uint
 	PluginMaxPort uint
 
+	// Env is added to the environment plugins run with
+	Env []string
+
 	Builders       map[string]string
 	Provisioners   map[string]string
 	PostProcessors map[string]string
@@ -145,6 +149,10 @@ func (c *Config) pluginClient(path string) *plugin.Client {
 	var config plugin.ClientConfig
 
 	config.Cmd = exec.Command(path)
+	if len(c.Env) > 0 {
+		config.Cmd.Env = append(os.Environ(), c.Env...)
+	}
+
 	config.Managed = true
 	config.MinPort = c.PluginMinPort
 	config.MaxPort = c.PluginMaxPort
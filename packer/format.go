@@ -0,0 +1,134 @@
+package packer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Extractor unpacks an archive into a directory
+type Extractor func(r io.Reader, dst string, limits Limits) error
+
+// Format of a template archive
+type Format struct {
+	Name       string
+	Extensions []string
+	Extract    Extractor
+
+	magicOffset int
+	magic       []byte
+}
+
+var (
+	formats []*Format
+)
+
+func init() {
+	RegisterFormat(&Format{
+		Name:       "zip",
+		Extensions: []string{".zip"},
+		Extract:    UnzipReaderWithLimits,
+		magic:      []byte("PK\x03\x04"),
+	})
+
+	RegisterFormat(&Format{
+		Name:       "tar.gz",
+		Extensions: []string{".tar.gz", ".tgz"},
+		Extract:    UntarGzipReader,
+		magic:      []byte{0x1f, 0x8b},
+	})
+
+	RegisterFormat(&Format{
+		Name:       "tar.bz2",
+		Extensions: []string{".tar.bz2", ".tbz2"},
+		Extract:    UntarBzip2Reader,
+		magic:      []byte("BZh"),
+	})
+
+	RegisterFormat(&Format{
+		Name:        "tar",
+		Extensions:  []string{".tar"},
+		Extract:     UntarReader,
+		magicOffset: 257,
+		magic:       []byte("ustar"),
+	})
+}
+
+// RegisterFormat adds an archive format, formats registered first are
+// tried first when looking up templates
+func RegisterFormat(f *Format) {
+	formats = append(formats, f)
+}
+
+// Formats returns the registered archive formats
+func Formats() []*Format {
+	return formats
+}
+
+// FormatByName finds a format by its name, e.g. "tar.gz"
+func FormatByName(name string) (*Format, bool) {
+	for _, f := range formats {
+		if f.Name == strings.TrimPrefix(name, ".") {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
+
+// FormatForKey finds a format by the extension of a file name or key
+func FormatForKey(key string) (*Format, bool) {
+	var (
+		match *Format
+		ext   string
+	)
+
+	// Longest extension wins so ".tar.gz" isn't mistaken for ".gz"
+	for _, f := range formats {
+		for _, e := range f.Extensions {
+			if strings.HasSuffix(key, e) && len(e) > len(ext) {
+				match, ext = f, e
+			}
+		}
+	}
+
+	return match, match != nil
+}
+
+// DetectFormat finds a format from the first bytes of an archive
+func DetectFormat(header []byte) (*Format, bool) {
+	for _, f := range formats {
+		if len(f.magic) == 0 || len(header) < f.magicOffset+len(f.magic) {
+			continue
+		}
+
+		if bytes.Equal(header[f.magicOffset:f.magicOffset+len(f.magic)], f.magic) {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
+
+// Extract unpacks the archive in r to dst. The format is taken from the
+// extension of key, falling back to the contents of the archive
+func Extract(r io.Reader, key string, dst string) error {
+	if f, ok := FormatForKey(key); ok {
+		return f.Extract(r, dst, ArchiveLimits)
+	}
+
+	br := bufio.NewReaderSize(r, 512)
+	header, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return fmt.Errorf("Unable to read archive: %v", err)
+	}
+
+	f, ok := DetectFormat(header)
+	if !ok {
+		return fmt.Errorf("Unknown archive format for %q", key)
+	}
+
+	return f.Extract(br, dst, ArchiveLimits)
+}
@@ -0,0 +1,114 @@
+package packer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// base.json and scripts/setup.sh (0755) as a tar.bz2, there's no bzip2
+// writer in the standard library
+const testTarBz2 = "QlpoOTFBWSZTWdfPe1QAAIz7gMuQBABoAfeAQIB6cd4KCAggAHIaUaB6mgAAaaDNQJJNSaPSNB6TQY0QyNNb8eQOgCP6FKSEWWfC194UHEqNohDAcIKWuWdukuxUCiMCJzcQ5f3aXiBJ+uDTIxZH09sbcQVu17o0hcHr8Us4EmBhnJoOvrwRAfi7kinChIa+e9qg"
+
+func buildTar(t *testing.T, entries []testEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+
+	w := tar.NewWriter(&buf)
+	for _, e := range entries {
+		if err := w.WriteHeader(&tar.Header{
+			Name: e.name,
+			Mode: int64(e.mode.Perm()),
+			Size: int64(len(e.body)),
+		}); err != nil {
+			t.Fatalf("Unable to write header: %v", err)
+		}
+
+		w.Write([]byte(e.body))
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unable to write tar: %v", err)
+	}
+
+	return &buf
+}
+
+func buildTarGz(t *testing.T, entries []testEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	io.Copy(w, buildTar(t, entries))
+	w.Close()
+
+	return &buf
+}
+
+func TestFormatForKey(t *testing.T) {
+	tests := map[string]string{
+		"templates/base.zip":     "zip",
+		"templates/base.tar":     "tar",
+		"templates/base.tar.gz":  "tar.gz",
+		"templates/base.tgz":     "tar.gz",
+		"templates/base.tar.bz2": "tar.bz2",
+	}
+
+	for key, name := range tests {
+		f, ok := FormatForKey(key)
+		if !ok || f.Name != name {
+			t.Errorf("Expected %q to be %s, got %v", key, name, f)
+		}
+	}
+
+	if _, ok := FormatForKey("templates/base.json"); ok {
+		t.Error("Unknown extension matched a format")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	entries := []testEntry{
+		{"base.json", 0644, "{}"},
+		{"scripts/setup.sh", 0755, "#!/bin/sh\n"},
+	}
+
+	bz2, _ := base64.StdEncoding.DecodeString(testTarBz2)
+
+	tests := []struct {
+		key  string
+		data []byte
+	}{
+		{"base.zip", buildZip(t, entries).Bytes()},
+		{"base.tar", buildTar(t, entries).Bytes()},
+		{"base.tar.gz", buildTarGz(t, entries).Bytes()},
+		{"base.tar.bz2", bz2},
+		// No extension, detected from the contents
+		{"zip", buildZip(t, entries).Bytes()},
+		{"tar", buildTar(t, entries).Bytes()},
+		{"targz", buildTarGz(t, entries).Bytes()},
+		{"tarbz2", bz2},
+	}
+
+	for _, tt := range tests {
+		dst, err := ioutil.TempDir("", "bakery-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := Extract(bytes.NewReader(tt.data), tt.key, dst); err != nil {
+			t.Errorf("%s: unable to extract: %v", tt.key, err)
+		} else if fi, err := os.Stat(filepath.Join(dst, "scripts/setup.sh")); err != nil || fi.Mode().Perm() != 0755 {
+			t.Errorf("%s: script not extracted correctly: %v", tt.key, err)
+		}
+
+		os.RemoveAll(dst)
+	}
+
+	if err := Extract(bytes.NewReader([]byte("not an archive")), "base", os.TempDir()); err == nil {
+		t.Error("Unknown format was extracted")
+	}
+}
@@ -5,7 +5,11 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	log "github.com/cihub/seelog"
 
@@ -21,12 +25,30 @@ const (
 	PluginMinPort = 15000
 )
 
+var (
+	// ErrCancelled is returned for builds that were cancelled before running
+	ErrCancelled = fmt.Errorf("Build was cancelled")
+)
+
+// BuilderUi is implemented by UIs that can tag output with the builder
+// it came from
+type BuilderUi interface {
+	ForBuilder(name string) packer.Ui
+}
+
 // Packer data store
 type Packer struct {
 	Template *template.Template
 
+	// Env is added to the environment of the plugins builds run in
+	Env []string
+
 	coreConfig *packer.CoreConfig
 	ui         packer.Ui
+
+	lock      sync.Mutex
+	cancelled bool
+	running   map[string]packer.Build
 }
 
 // New creates a new packer object
@@ -39,16 +61,42 @@ func New(t io.ReadCloser, ui packer.Ui) (*Packer, error) {
 	return &Packer{
 		Template: tpl,
 		ui:       ui,
+		running:  map[string]packer.Build{},
 	}, nil
 }
 
 // Build performs the final build
-func (p *Packer) Build(variables map[string]*Variable) (map[string][]packer.Artifact, error) {
+func (p *Packer) Build(variables map[string]*Variable) (BuildResults, error) {
+	core, err := p.NewCore(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	builds, err := p.ListBuilds(core)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list builds: %v", err)
+	}
+
+	results := p.ProcessBuilds(builds)
+	if errs := results.Errors(); len(errs) > 0 {
+		return results, fmt.Errorf("Unable to process %d of %d builds", len(errs), len(results))
+	}
+
+	return results, nil
+}
+
+// NewCore discovers plugins and creates a core for the template
+func (p *Packer) NewCore(variables map[string]*Variable) (*packer.Core, error) {
 	config := NewConfig(PluginMinPort, PluginMaxPort)
+	config.Env = p.Env
 	if err := config.Discover(); err != nil {
 		return nil, fmt.Errorf("Unable to discover packer config: %v", err)
 	}
 
+	return p.newCore(config, variables)
+}
+
+func (p *Packer) newCore(config *Config, variables map[string]*Variable) (*packer.Core, error) {
 	p.coreConfig = p.BuildCoreConfig(config, variables)
 
 	core, err := packer.NewCore(p.coreConfig)
@@ -56,17 +104,7 @@ func (p *Packer) Build(variables map[string]*Variable) (map[string][]packer.Arti
 		return nil, fmt.Errorf("Unable to create new core: %v", err)
 	}
 
-	builds, err := p.ListBuilds(core)
-	if err != nil {
-		return nil, fmt.Errorf("Unable to list builds: %v", err)
-	}
-
-	artifacts, errs := p.ProcessBuilds(builds)
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("Unable to process builds")
-	}
-
-	return artifacts, nil
+	return core, nil
 }
 
 // BuildCoreConfig compiles config
@@ -100,63 +138,206 @@ func (p *Packer) ListBuilds(core *packer.Core) ([]packer.Build, error) {
 	return builds, nil
 }
 
-// ProcessBuilds builds individual builds
-func (p *Packer) ProcessBuilds(builds []packer.Build) (map[string][]packer.Artifact, map[string]error) {
-	artifacts := map[string][]packer.Artifact{}
-	errors := map[string]error{}
+// ProcessBuilds runs the builds in parallel and returns a result for
+// each of them, in the same order as builds
+func (p *Packer) ProcessBuilds(builds []packer.Build) BuildResults {
+	results := make(BuildResults, len(builds))
+
+	// The cache is shared between the builds, as packer itself does
+	cacheDir, err := ioutil.TempDir(os.TempDir(), "bakery")
+	if err != nil {
+		for i, b := range builds {
+			results[i] = &BuildResult{
+				Name:  b.Name(),
+				Error: fmt.Errorf("Unable to create cache directory: %v", err),
+			}
+		}
+
+		return results
+	}
+
+	defer os.RemoveAll(cacheDir)
+
+	log.Infof("Setting cache directory: %s", cacheDir)
+	cache := &packer.FileCache{CacheDir: cacheDir}
 
 	var wg sync.WaitGroup
-	for _, b := range builds {
+	for i, b := range builds {
 		log.Infof("Processing build %q", b.Name())
 		wg.Add(1)
 
-		cacheDir, _ := ioutil.TempDir("/tmp", "bakery")
+		go func(i int, b packer.Build) {
+			defer wg.Done()
 
-		defer os.RemoveAll(cacheDir)
+			results[i] = p.processBuild(b, cache)
+			p.machineResult(results[i])
+		}(i, b)
+	}
 
-		log.Infof("Setting cache directory: %s", cacheDir)
-		cache := &packer.FileCache{CacheDir: cacheDir}
+	log.Infof("Waiting for builds to complete")
+	wg.Wait()
 
-		go func(b packer.Build) {
-			defer wg.Done()
+	errs := results.Errors()
+	if p.ui != nil {
+		p.ui.Machine("error-count", strconv.Itoa(len(errs)))
+	}
 
-			log.Infof("Preparing build for %q", b.Name())
-			warnings, err := b.Prepare()
-			if err != nil {
-				log.Errorf("Problem preparing the build for %q: %v", b.Name(), err)
-				errors[b.Name()] = err
-				return
-			}
+	if len(errs) > 0 {
+		log.Error("There were some problems building")
+		for n, e := range errs {
+			log.Errorf("%s: %v", n, e)
+		}
+	}
 
-			for _, w := range warnings {
-				log.Debugf("Warning for %q: %v", b.Name(), w)
-			}
+	return results
+}
 
-			runArtifacts, err := b.Run(p.ui, cache)
+func (p *Packer) processBuild(b packer.Build, cache packer.Cache) (result *BuildResult) {
+	start := time.Now()
+	result = &BuildResult{
+		Name: b.Name(),
+	}
 
-			if err != nil {
-				log.Errorf("Build '%s' errored: %s", b.Name(), err)
-				errors[b.Name()] = err
-			} else {
-				log.Infof("Build '%s' finished.", b.Name())
-				artifacts[b.Name()] = runArtifacts
-			}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	log.Infof("Preparing build for %q", b.Name())
+	warnings, err := b.Prepare()
+	result.Warnings = warnings
+	if err != nil {
+		log.Errorf("Problem preparing the build for %q: %v", b.Name(), err)
+		result.Error = err
+		return
+	}
+
+	for _, w := range warnings {
+		log.Debugf("Warning for %q: %v", b.Name(), w)
+	}
+
+	if !p.startRun(b) {
+		log.Infof("Build %q was cancelled before running", b.Name())
+		result.Error = ErrCancelled
+		return
+	}
+
+	result.Artifacts, result.Error = p.runBuild(b, cache)
+
+	if result.Error != nil {
+		log.Errorf("Build '%s' errored: %s", b.Name(), result.Error)
+		return
+	}
+
+	log.Infof("Build '%s' finished.", b.Name())
+
+	return
+}
+
+// runBuild runs a build that has been started, turning a panic into its
+// error so every build ends with a result
+func (p *Packer) runBuild(b packer.Build, cache packer.Cache) (artifacts []packer.Artifact, err error) {
+	defer p.endRun(b)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Build %q panicked: %v", b.Name(), r)
+		}
+	}()
+
+	return b.Run(p.builderUi(b.Name()), cache)
+}
+
+// machineResult writes the result of a build as machine readable
+// messages, in the same way as packer build. The messages are made from
+// the build's result once it returns, not from its own output, so they
+// only let the result be seen as each build finishes
+func (p *Packer) machineResult(r *BuildResult) {
+	ui := p.builderUi(r.Name)
+	if ui == nil {
+		return
+	}
+
+	if r.Error != nil {
+		ui.Machine("error", r.Error.Error())
+		return
+	}
+
+	ui.Machine("artifact-count", strconv.Itoa(len(r.Artifacts)))
+	for i, a := range r.Artifacts {
+		index := strconv.Itoa(i)
+		if a == nil {
+			ui.Machine("artifact", index, "nil")
+			continue
+		}
+
+		ui.Machine("artifact", index, "builder-id", a.BuilderId())
+		ui.Machine("artifact", index, "id", a.Id())
+		ui.Machine("artifact", index, "string", a.String())
+
+		files := a.Files()
+		ui.Machine("artifact", index, "files-count", strconv.Itoa(len(files)))
+		for j, f := range files {
+			ui.Machine("artifact", index, "file", strconv.Itoa(j), f)
+		}
+
+		ui.Machine("artifact", index, "end")
+	}
+}
+
+// builderUi returns the UI for a build, tagged with its name if the UI
+// supports it
+func (p *Packer) builderUi(name string) packer.Ui {
+	if bu, ok := p.ui.(BuilderUi); ok {
+		return bu.ForBuilder(name)
+	}
+
+	return p.ui
+}
+
+// Cancel stops every running build and waits for them to clean up.
+// Builds that haven't started running yet won't be run
+func (p *Packer) Cancel() {
+	p.lock.Lock()
+	p.cancelled = true
+
+	var builds []packer.Build
+	for _, b := range p.running {
+		builds = append(builds, b)
+	}
+	p.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, b := range builds {
+		wg.Add(1)
+
+		go func(b packer.Build) {
+			defer wg.Done()
+
+			log.Infof("Cancelling build %q", b.Name())
+			b.Cancel()
 		}(b)
 	}
 
-	log.Infof("Waiting for builds to complete")
 	wg.Wait()
+}
 
-	if len(errors) > 0 {
-		log.Error("There were some problems building")
-		for n, e := range errors {
-			log.Errorf("%s: %v", n, e)
-		}
+func (p *Packer) startRun(b packer.Build) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
 
-		return nil, errors
+	if p.cancelled {
+		return false
 	}
 
-	return artifacts, nil
+	p.running[b.Name()] = b
+
+	return true
+}
+
+func (p *Packer) endRun(b packer.Build) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.running, b.Name())
 }
 
 // ListTemplateVariables extracts variables from a template
@@ -174,7 +355,7 @@ func (p *Packer) ListTemplateVariables() map[string]*Variable {
 func (p *Packer) extractVariables(vars map[string]*Variable) map[string]string {
 	_vars := map[string]string{}
 	for n, v := range vars {
-		_vars[n] = v.Default
+		_vars[n] = v.Value
 	}
 
 	log.Infof("Extracted vars: %#v", _vars)
@@ -198,19 +379,22 @@ func ReadTemplate(t io.ReadCloser) (*template.Template, error) {
 	return tpl, nil
 }
 
-// ExtractVariables maps template variables with the template
-func ExtractVariables(vars map[string]*template.Variable, values map[string]string) map[string]*Variable {
+// ExtractVariables maps template variables to values. Each variable takes
+// its value from the first of values that sets it, falling back to the
+// template default
+func ExtractVariables(vars map[string]*template.Variable, values ...map[string]string) map[string]*Variable {
 	_vars := map[string]*Variable{}
 
 	for k, v := range vars {
-		for name, value := range values {
-			_vars[k] = &Variable{
-				Variable: v,
-			}
+		_vars[k] = &Variable{
+			Variable: v,
+			Value:    v.Default,
+		}
 
-			if name == k {
+		for _, vals := range values {
+			if value, ok := vals[k]; ok {
 				_vars[k].Value = value
-				_vars[k].Default = value
+				break
 			}
 		}
 	}
@@ -218,13 +402,26 @@ func ExtractVariables(vars map[string]*template.Variable, values map[string]stri
 	return _vars
 }
 
-// CheckVariables ensures variables are set
+// CheckVariables ensures required variables are set
 func CheckVariables(vars map[string]*Variable) (bool, error) {
+	if missing := MissingVariables(vars); len(missing) > 0 {
+		return false, fmt.Errorf("Variables not set, but required: %s", strings.Join(missing, ", "))
+	}
+
+	return true, nil
+}
+
+// MissingVariables returns the sorted names of required variables that
+// aren't set
+func MissingVariables(vars map[string]*Variable) []string {
+	var missing []string
 	for n, v := range vars {
-		if len(v.Value) == 0 {
-			return false, fmt.Errorf("Variable %q is not set, but requried", n)
+		if v.Required && len(v.Value) == 0 {
+			missing = append(missing, n)
 		}
 	}
 
-	return true, nil
+	sort.Strings(missing)
+
+	return missing
 }
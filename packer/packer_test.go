@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	// "github.com/hailocab/bakery-service/packer/ui"
+
+	"github.com/mitchellh/packer/template"
 )
 
 var (
@@ -98,3 +100,50 @@ func (m *MockReadCloser) Read(p []byte) (n int, err error) {
 func (m *MockReadCloser) Close() error {
 	return nil
 }
+
+func TestExtractVariablesPrecedence(t *testing.T) {
+	tplVars := map[string]*template.Variable{
+		"cwd":     {Default: "default"},
+		"ami":     {Default: "ami-default"},
+		"version": {Default: "1"},
+		"region":  {Required: true},
+	}
+
+	vars := ExtractVariables(tplVars,
+		map[string]string{"cwd": "/tmp/bakery"},
+		map[string]string{"cwd": "/elsewhere", "ami": "ami-123"},
+	)
+
+	expected := map[string]string{
+		"cwd":     "/tmp/bakery",
+		"ami":     "ami-123",
+		"version": "1",
+		"region":  "",
+	}
+
+	for k, v := range expected {
+		if vars[k].Value != v {
+			t.Fatalf("Expected %q to be %q, got %q", k, v, vars[k].Value)
+		}
+	}
+
+	if tplVars["ami"].Default != "ami-default" {
+		t.Fatal("Template default was modified")
+	}
+}
+
+func TestCheckVariables(t *testing.T) {
+	tplVars := map[string]*template.Variable{
+		"optional": {Default: ""},
+		"region":   {Required: true},
+	}
+
+	if ok, err := CheckVariables(ExtractVariables(tplVars)); ok || err == nil {
+		t.Fatal("Missing required variable was accepted")
+	}
+
+	ok, err := CheckVariables(ExtractVariables(tplVars, map[string]string{"region": "eu-west-1"}))
+	if !ok || err != nil {
+		t.Fatalf("Required variable set but rejected: %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package packer
 
 import (
 	"path/filepath"
+	"time"
 
+	"github.com/mitchellh/packer/packer"
 	"github.com/mitchellh/packer/template"
 )
 
@@ -20,3 +22,39 @@ type Variable struct {
 
 	Value string
 }
+
+// BuildResult is the outcome of a single build
+type BuildResult struct {
+	Name      string
+	Artifacts []packer.Artifact
+	Warnings  []string
+	Error     error
+	Duration  time.Duration
+}
+
+// BuildResults of every build in a template
+type BuildResults []*BuildResult
+
+// Errors returns the errors keyed by build name
+func (r BuildResults) Errors() map[string]error {
+	errs := map[string]error{}
+	for _, res := range r {
+		if res.Error != nil {
+			errs[res.Name] = res.Error
+		}
+	}
+
+	return errs
+}
+
+// Artifacts returns the artifacts keyed by build name
+func (r BuildResults) Artifacts() map[string][]packer.Artifact {
+	artifacts := map[string][]packer.Artifact{}
+	for _, res := range r {
+		if len(res.Artifacts) > 0 {
+			artifacts[res.Name] = res.Artifacts
+		}
+	}
+
+	return artifacts
+}
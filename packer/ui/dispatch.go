@@ -0,0 +1,300 @@
+package ui
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/cihub/seelog"
+)
+
+// Policy decides what happens to a message when a caller's buffer is full
+type Policy int
+
+const (
+	// PolicyBlock waits for the caller to make room, slowing the build down
+	PolicyBlock Policy = iota
+
+	// PolicyDrop discards the message, counting it as dropped
+	PolicyDrop
+)
+
+// CallerOptions control how messages are delivered to a caller
+type CallerOptions struct {
+	// BufferSize is how many messages can wait for the caller
+	BufferSize int
+
+	// Policy applies once the buffer is full
+	Policy Policy
+
+	// Retries is how many more times a failed call is attempted
+	Retries int
+
+	// Backoff is the wait before the first retry, doubling after each one
+	Backoff time.Duration
+}
+
+var (
+	// DefaultCallerOptions are used by AddCaller
+	DefaultCallerOptions = CallerOptions{
+		BufferSize: 1000,
+		Policy:     PolicyBlock,
+		Retries:    3,
+		Backoff:    100 * time.Millisecond,
+	}
+)
+
+// CallerStats counts what happened to the messages sent to a caller
+type CallerStats struct {
+	Delivered uint64
+	Dropped   uint64
+	Retries   uint64
+	Errors    uint64
+}
+
+// delivery feeds a caller from its own queue and goroutine. Messages are
+// queued without ever waiting, so the order they're queued in is kept;
+// writers wait for room afterwards, if the caller's policy is to block
+type delivery struct {
+	name   string
+	caller Caller
+	opts   CallerOptions
+
+	lock   sync.Mutex
+	cond   *sync.Cond
+	queue  []*Message
+	closed bool
+	done   chan struct{}
+
+	delivered uint64
+	dropped   uint64
+	retries   uint64
+	errors    uint64
+}
+
+func newDelivery(name string, caller Caller, opts CallerOptions) *delivery {
+	if opts.BufferSize < 0 {
+		opts.BufferSize = 0
+	}
+
+	d := &delivery{
+		name:   name,
+		caller: caller,
+		opts:   opts,
+		done:   make(chan struct{}),
+	}
+
+	d.cond = sync.NewCond(&d.lock)
+
+	return d
+}
+
+// run delivers queued messages until the delivery is closed and its
+// queue is empty
+func (d *delivery) run() {
+	defer close(d.done)
+
+	for {
+		d.lock.Lock()
+		for len(d.queue) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+
+		if len(d.queue) == 0 {
+			d.lock.Unlock()
+			return
+		}
+
+		msg := d.queue[0]
+		d.queue[0] = nil
+		d.queue = d.queue[1:]
+
+		// Writers waiting for room can carry on
+		d.cond.Broadcast()
+		d.lock.Unlock()
+
+		d.deliver(msg)
+	}
+}
+
+// deliver calls the caller, retrying with backoff until it succeeds or
+// runs out of retries
+func (d *delivery) deliver(msg *Message) {
+	backoff := d.opts.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err := d.caller.Call(msg)
+		if err == nil {
+			atomic.AddUint64(&d.delivered, 1)
+			return
+		}
+
+		if attempt >= d.opts.Retries {
+			atomic.AddUint64(&d.errors, 1)
+			log.Errorf("[%s] Caller %q failed on message %d: %v", msg.ID, d.name, msg.Sequence, err)
+			return
+		}
+
+		atomic.AddUint64(&d.retries, 1)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// enqueue queues msg without waiting. Callers that drop messages drop it
+// if their buffer is full, the others queue it and are waited on by wait
+func (d *delivery) enqueue(msg *Message) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.closed || (d.opts.Policy == PolicyDrop && len(d.queue) >= d.opts.BufferSize) {
+		atomic.AddUint64(&d.dropped, 1)
+		return
+	}
+
+	d.queue = append(d.queue, msg)
+	d.cond.Broadcast()
+}
+
+// wait blocks until the caller's buffer has room again
+func (d *delivery) wait() {
+	if d.opts.Policy != PolicyBlock {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for len(d.queue) > d.opts.BufferSize && !d.closed {
+		d.cond.Wait()
+	}
+}
+
+// close stops the delivery once its queue is empty
+func (d *delivery) close() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.closed = true
+	d.cond.Broadcast()
+}
+
+func (d *delivery) stats() CallerStats {
+	return CallerStats{
+		Delivered: atomic.LoadUint64(&d.delivered),
+		Dropped:   atomic.LoadUint64(&d.dropped),
+		Retries:   atomic.LoadUint64(&d.retries),
+		Errors:    atomic.LoadUint64(&d.errors),
+	}
+}
+
+// dispatcher fans the messages of a build out to its callers, in the
+// order the callers were added. It's shared by the UIs of every builder
+type dispatcher struct {
+	sync.Mutex
+
+	id         string
+	deliveries []*delivery
+	sequence   uint64
+	started    bool
+	closed     bool
+}
+
+func (d *dispatcher) add(name string, caller Caller, opts CallerOptions) {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.started || d.closed {
+		log.Errorf("Not adding caller %q to a UI that's in use", name)
+		return
+	}
+
+	d.deliveries = append(d.deliveries, newDelivery(name, caller, opts))
+}
+
+// dispatch numbers msg and queues a copy for each caller. The lock keeps
+// the sequence and the order messages are queued in the same, queueing
+// never waits so it's released before waiting on callers that block.
+// Callers are only started by the first message, so a UI that's never
+// written to doesn't need closing
+func (d *dispatcher) dispatch(msg Message) {
+	d.Lock()
+
+	if d.closed {
+		for _, dl := range d.deliveries {
+			atomic.AddUint64(&dl.dropped, 1)
+		}
+
+		d.Unlock()
+		return
+	}
+
+	if !d.started {
+		d.started = true
+		for _, dl := range d.deliveries {
+			go dl.run()
+		}
+	}
+
+	d.sequence++
+	msg.Sequence = d.sequence
+
+	for _, dl := range d.deliveries {
+		log.Debugf("Calling %q: %s - %s", dl.name, msg.Type.String(), msg.Message)
+
+		// Each caller gets its own copy
+		m := msg
+		dl.enqueue(&m)
+	}
+
+	d.Unlock()
+
+	// A slow caller only holds up the writer, its message is already
+	// queued for the other callers
+	for _, dl := range d.deliveries {
+		dl.wait()
+	}
+}
+
+// close stops accepting messages and waits for every caller to be
+// handed the messages queued for it. Callers that lost messages are logged
+func (d *dispatcher) close() {
+	d.Lock()
+	if d.closed {
+		d.Unlock()
+		return
+	}
+
+	d.closed = true
+	started := d.started
+	d.Unlock()
+
+	if !started {
+		return
+	}
+
+	for _, dl := range d.deliveries {
+		dl.close()
+	}
+
+	for _, dl := range d.deliveries {
+		<-dl.done
+
+		if s := dl.stats(); s.Dropped > 0 || s.Errors > 0 {
+			log.Warnf("[%s] Caller %q dropped %d and failed %d of %d messages",
+				d.id, dl.name, s.Dropped, s.Errors, s.Delivered+s.Dropped+s.Errors)
+		}
+	}
+}
+
+func (d *dispatcher) stats() map[string]CallerStats {
+	d.Lock()
+	defer d.Unlock()
+
+	stats := make(map[string]CallerStats, len(d.deliveries))
+	for _, dl := range d.deliveries {
+		stats[dl.name] = dl.stats()
+	}
+
+	return stats
+}
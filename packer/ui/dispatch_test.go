@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/packer/packer"
+)
+
+// flakyCaller fails the first failures calls
+type flakyCaller struct {
+	recordingCaller
+	failures int
+}
+
+func (fc *flakyCaller) Call(msg *Message) error {
+	fc.Lock()
+	if fc.failures > 0 {
+		fc.failures--
+		fc.Unlock()
+		return fmt.Errorf("Unavailable")
+	}
+	fc.Unlock()
+
+	return fc.recordingCaller.Call(msg)
+}
+
+// blockingCaller waits for release before returning
+type blockingCaller struct {
+	recordingCaller
+	release chan struct{}
+}
+
+func (bc *blockingCaller) Call(msg *Message) error {
+	<-bc.release
+	return bc.recordingCaller.Call(msg)
+}
+
+func TestCallersDontBlock(t *testing.T) {
+	bc := &blockingCaller{release: make(chan struct{})}
+	rc := &recordingCaller{}
+
+	ui := New("abc",
+		AddCallerWithOptions("slow", bc, CallerOptions{BufferSize: 1, Policy: PolicyDrop}),
+		AddCaller("recorder", rc),
+	)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			ui.Say("Saying")
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a slow caller not to block the UI")
+	}
+
+	close(bc.release)
+	ui.Close()
+
+	if len(rc.msgs) != 10 {
+		t.Errorf("Expected every message to be recorded, got %d", len(rc.msgs))
+	}
+
+	stats := ui.Stats()["slow"]
+	if stats.Dropped == 0 || stats.Delivered+stats.Dropped != 10 {
+		t.Errorf("Expected the slow caller to drop messages, got %+v", stats)
+	}
+
+	// Messages are delivered in sequence
+	for i, msg := range rc.msgs {
+		if msg.Sequence != uint64(i+1) {
+			t.Fatalf("Expected sequence %d, got %d", i+1, msg.Sequence)
+		}
+	}
+}
+
+// waitForMessages waits for rc to record n messages
+func waitForMessages(t *testing.T, rc *recordingCaller, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		rc.Lock()
+		got := len(rc.msgs)
+		rc.Unlock()
+
+		if got >= n {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a blocking caller not to stall the others, recorded %d of %d messages", got, n)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBlockingCallerDoesntStallOthers(t *testing.T) {
+	bc := &blockingCaller{release: make(chan struct{})}
+	rc := &recordingCaller{}
+
+	ui := New("abc",
+		AddCallerWithOptions("slow", bc, CallerOptions{BufferSize: 1, Policy: PolicyBlock}),
+		AddCaller("recorder", rc),
+	)
+
+	// The first builder fills the slow caller's buffer and waits for room,
+	// the second is still heard by the other callers
+	go func() {
+		b := ui.ForBuilder("amazon-ebs")
+		for i := 0; i < 3; i++ {
+			b.Say("Building")
+		}
+	}()
+
+	waitForMessages(t, rc, 3)
+	go ui.ForBuilder("docker").Say("Building")
+	waitForMessages(t, rc, 4)
+
+	close(bc.release)
+	ui.Close()
+
+	if len(rc.msgs) != 4 || len(bc.msgs) != 4 {
+		t.Fatalf("Expected every message to be delivered, got %d and %d", len(rc.msgs), len(bc.msgs))
+	}
+
+	if s := ui.Stats()["slow"]; s.Dropped != 0 {
+		t.Errorf("Expected a blocking caller not to drop messages, got %+v", s)
+	}
+
+	// Both callers see the messages in sequence
+	for i := range rc.msgs {
+		if rc.msgs[i].Sequence != uint64(i+1) || bc.msgs[i].Sequence != uint64(i+1) {
+			t.Fatalf("Expected sequence %d, got %d and %d", i+1, rc.msgs[i].Sequence, bc.msgs[i].Sequence)
+		}
+	}
+}
+
+func TestCallerRetries(t *testing.T) {
+	fc := &flakyCaller{failures: 2}
+	failing := &flakyCaller{failures: 100}
+
+	ui := New("abc",
+		AddCallerWithOptions("flaky", fc, CallerOptions{Retries: 2, Backoff: time.Millisecond}),
+		AddCallerWithOptions("failing", failing, CallerOptions{Retries: 1, Backoff: time.Millisecond}),
+	)
+
+	ui.Say("Saying")
+	ui.Close()
+
+	if len(fc.msgs) != 1 {
+		t.Fatalf("Expected the message to be retried, got %d", len(fc.msgs))
+	}
+
+	stats := ui.Stats()
+	if s := stats["flaky"]; s.Delivered != 1 || s.Retries != 2 || s.Errors != 0 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+
+	if s := stats["failing"]; s.Delivered != 0 || s.Retries != 1 || s.Errors != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
+
+func TestClose(t *testing.T) {
+	rc := &recordingCaller{}
+	ui := New("abc", AddCaller("recorder", rc))
+
+	// Closing an unused UI doesn't wait on anything
+	New("def", AddCaller("recorder", &recordingCaller{})).Close()
+
+	var wg sync.WaitGroup
+	for _, builder := range []string{"amazon-ebs", "docker"} {
+		wg.Add(1)
+		go func(b packer.Ui) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				b.Say("Building")
+			}
+		}(ui.ForBuilder(builder))
+	}
+
+	wg.Wait()
+	ui.Close()
+
+	if len(rc.msgs) != 100 {
+		t.Fatalf("Expected every message to be flushed, got %d", len(rc.msgs))
+	}
+
+	ui.Say("Too late")
+	ui.Close()
+
+	if len(rc.msgs) != 100 || ui.Stats()["recorder"].Dropped != 1 {
+		t.Errorf("Expected messages after close to be dropped, got %+v", ui.Stats()["recorder"])
+	}
+}
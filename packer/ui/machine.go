@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"strconv"
+	"sync"
+)
+
+// MachineMessage is a machine readable message, as packer writes with
+// -machine-readable
+type MachineMessage struct {
+	Type   string
+	Target string
+	Data   []string
+}
+
+// Artifact is an artifact announced by machine readable messages
+type Artifact struct {
+	Builder     string
+	BuilderID   string
+	ID          string
+	Description string
+	Files       []string
+}
+
+// Progress of a builder, derived from its machine readable messages
+type Progress struct {
+	Builder   string
+	Artifacts []*Artifact
+	Errors    []string
+
+	// Expected is the number of artifacts announced, -1 until known
+	Expected int
+	Finished bool
+}
+
+// Tracker is a caller deriving artifacts and builder progress from the
+// machine readable messages of a build. OnArtifact is called as each
+// artifact is complete and OnFinish as each builder finishes
+type Tracker struct {
+	sync.Mutex
+
+	OnArtifact func(a *Artifact)
+	OnFinish   func(p *Progress)
+
+	builders map[string]*Progress
+	pending  map[string]map[string]*Artifact
+}
+
+// NewTracker creates a tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		builders: map[string]*Progress{},
+		pending:  map[string]map[string]*Artifact{},
+	}
+}
+
+// Progress returns the progress of every builder seen so far
+func (t *Tracker) Progress() map[string]Progress {
+	t.Lock()
+	defer t.Unlock()
+
+	progress := make(map[string]Progress, len(t.builders))
+	for n, p := range t.builders {
+		progress[n] = *p
+	}
+
+	return progress
+}
+
+// Call parses machine readable messages, ignoring everything else
+func (t *Tracker) Call(msg *Message) error {
+	m := msg.Machine
+	if m == nil || len(m.Target) == 0 {
+		return nil
+	}
+
+	t.Lock()
+
+	var (
+		artifact *Artifact
+		finished *Progress
+	)
+
+	p := t.progress(m.Target)
+	switch m.Type {
+	case "artifact-count":
+		if len(m.Data) > 0 {
+			if n, err := strconv.Atoi(m.Data[0]); err == nil {
+				p.Expected = n
+			}
+		}
+	case "artifact":
+		artifact = t.artifact(p, m.Data)
+	case "error":
+		if len(m.Data) > 0 {
+			p.Errors = append(p.Errors, m.Data[len(m.Data)-1])
+		}
+
+		if !p.Finished {
+			p.Finished = true
+			finished = p
+		}
+	}
+
+	if !p.Finished && p.Expected >= 0 && len(p.Artifacts) == p.Expected {
+		p.Finished = true
+		finished = p
+	}
+
+	t.Unlock()
+
+	if artifact != nil && t.OnArtifact != nil {
+		t.OnArtifact(artifact)
+	}
+
+	if finished != nil && t.OnFinish != nil {
+		t.OnFinish(finished)
+	}
+
+	return nil
+}
+
+func (t *Tracker) progress(builder string) *Progress {
+	p, ok := t.builders[builder]
+	if !ok {
+		p = &Progress{
+			Builder:  builder,
+			Expected: -1,
+		}
+
+		t.builders[builder] = p
+	}
+
+	return p
+}
+
+// artifact applies "artifact" data, <index>,<field>,<values...>, and
+// returns the artifact once it's complete
+func (t *Tracker) artifact(p *Progress, data []string) *Artifact {
+	if len(data) < 2 {
+		return nil
+	}
+
+	pending, ok := t.pending[p.Builder]
+	if !ok {
+		pending = map[string]*Artifact{}
+		t.pending[p.Builder] = pending
+	}
+
+	index, field, values := data[0], data[1], data[2:]
+
+	a, ok := pending[index]
+	if !ok {
+		a = &Artifact{Builder: p.Builder}
+		pending[index] = a
+	}
+
+	switch field {
+	case "builder-id":
+		if len(values) > 0 {
+			a.BuilderID = values[0]
+		}
+	case "id":
+		if len(values) > 0 {
+			a.ID = values[0]
+		}
+	case "string":
+		if len(values) > 0 {
+			a.Description = values[0]
+		}
+	case "file":
+		if len(values) > 1 {
+			a.Files = append(a.Files, values[1])
+		}
+	case "nil":
+		delete(pending, index)
+		p.Expected--
+	case "end":
+		delete(pending, index)
+		p.Artifacts = append(p.Artifacts, a)
+		return a
+	}
+
+	return nil
+}
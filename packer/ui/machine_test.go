@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"testing"
+)
+
+func TestMachineMessage(t *testing.T) {
+	rc := &recordingCaller{}
+	ui := New("abc", AddCaller("recorder", rc))
+
+	ui.ForBuilder("amazon-ebs").Machine("artifact", "0", "id", "eu-west-1:ami-123")
+	ui.Close()
+
+	if len(rc.msgs) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(rc.msgs))
+	}
+
+	m := rc.msgs[0].Machine
+	if m == nil || m.Type != "artifact" || m.Target != "amazon-ebs" || len(m.Data) != 3 || m.Data[2] != "eu-west-1:ami-123" {
+		t.Fatalf("Unexpected machine message %#v", m)
+	}
+
+	if rc.msgs[0].Message != "artifact: 0 id eu-west-1:ami-123" {
+		t.Errorf("Unexpected message %q", rc.msgs[0].Message)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	var (
+		artifacts []*Artifact
+		finished  []*Progress
+	)
+
+	tr := NewTracker()
+	tr.OnArtifact = func(a *Artifact) { artifacts = append(artifacts, a) }
+	tr.OnFinish = func(p *Progress) { finished = append(finished, p) }
+
+	ui := New("abc", AddCaller("tracker", tr))
+
+	ebs := ui.ForBuilder("amazon-ebs")
+	ebs.Say("Creating AMI")
+	ebs.Machine("artifact-count", "2")
+	ebs.Machine("artifact", "0", "builder-id", "mitchellh.amazonebs")
+	ebs.Machine("artifact", "0", "id", "eu-west-1:ami-123,us-east-1:ami-456")
+	ebs.Machine("artifact", "0", "string", "AMIs were created")
+	ebs.Machine("artifact", "0", "files-count", "1")
+	ebs.Machine("artifact", "0", "file", "0", "manifest.json")
+	ebs.Machine("artifact", "0", "end")
+	ebs.Machine("artifact", "1", "nil")
+
+	docker := ui.ForBuilder("docker")
+	docker.Machine("error", "Unable to pull image")
+	docker.Machine("error", "Duplicate")
+
+	// Untargeted messages, like error-count, aren't tracked
+	ui.Machine("error-count", "1")
+	ui.Close()
+
+	if len(artifacts) != 1 {
+		t.Fatalf("Expected 1 artifact, got %d", len(artifacts))
+	}
+
+	a := artifacts[0]
+	if a.Builder != "amazon-ebs" || a.BuilderID != "mitchellh.amazonebs" || a.ID != "eu-west-1:ami-123,us-east-1:ami-456" ||
+		a.Description != "AMIs were created" || len(a.Files) != 1 || a.Files[0] != "manifest.json" {
+		t.Errorf("Unexpected artifact %#v", a)
+	}
+
+	if len(finished) != 2 || finished[0].Builder != "amazon-ebs" || len(finished[0].Errors) != 0 {
+		t.Fatalf("Expected amazon-ebs to finish, got %#v", finished)
+	}
+
+	if finished[1].Builder != "docker" || finished[1].Errors[0] != "Unable to pull image" {
+		t.Fatalf("Expected docker to finish with an error, got %#v", finished)
+	}
+
+	progress := tr.Progress()
+	if len(progress) != 2 || !progress["amazon-ebs"].Finished || !progress["docker"].Finished {
+		t.Errorf("Unexpected progress %#v", progress)
+	}
+}
@@ -2,38 +2,68 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
-	log "github.com/cihub/seelog"
 	"github.com/mitchellh/packer/packer"
 )
 
-// Caller foo
+// Caller foo. Calls that error are retried
 type Caller interface {
-	Call(msg *Message)
+	Call(msg *Message) error
 }
 
-// Callers foo
-type Callers map[string]Caller
-
 // CallerFunc foo
-type CallerFunc func(c Callers)
+type CallerFunc func(ui *UI)
 
-// AddCaller foo
+// AddCaller foo, messages are delivered with DefaultCallerOptions
 func AddCaller(name string, caller Caller) CallerFunc {
-	return func(c Callers) {
-		c[name] = caller
+	return AddCallerWithOptions(name, caller, DefaultCallerOptions)
+}
+
+// AddCallerWithOptions adds a caller with its own buffer, full buffer
+// policy and retries
+func AddCallerWithOptions(name string, caller Caller, opts CallerOptions) CallerFunc {
+	return func(ui *UI) {
+		ui.dispatcher.add(name, caller, opts)
 	}
 }
 
 type callerType int
 
 func (ct callerType) String() string {
+	if ct <= 0 || int(ct) >= len(callerTypeDescs) {
+		return "Unknown"
+	}
+
 	return callerTypeDescs[ct]
 }
 
+// MarshalText encodes the type as its name
+func (ct callerType) MarshalText() ([]byte, error) {
+	return []byte(ct.String()), nil
+}
+
+// UnmarshalText decodes a type from its name
+func (ct *callerType) UnmarshalText(text []byte) error {
+	for i, d := range callerTypeDescs {
+		if i > 0 && strings.EqualFold(d, string(text)) {
+			*ct = callerType(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown message type %q", text)
+}
+
+// ValidType reports whether name is a message type
+func ValidType(name string) bool {
+	var ct callerType
+	return ct.UnmarshalText([]byte(name)) == nil
+}
+
 const (
-	callerTypeAsk callerType = 1
+	callerTypeAsk callerType = iota + 1
 	callerTypeSay
 	callerTypeMessage
 	callerTypeError
@@ -55,61 +85,108 @@ var (
 type Message struct {
 	Date    time.Time
 	ID      string
+	Builder string
+
+	// Sequence orders the messages of a build
+	Sequence uint64
+
 	Message string
 	Type    callerType
+
+	// Machine is set for machine readable messages
+	Machine *MachineMessage `json:",omitempty"`
 }
 
-// UI struct
+var _ packer.Ui = &UI{}
+
+// UI struct. Each caller is fed from its own goroutine, so a slow caller
+// doesn't hold up the build; Close waits for them to catch up
 type UI struct {
-	Callers Callers
+	// ID of the build messages belong to
+	ID string
+
+	// Builder messages come from, if any
+	Builder string
+
+	// dispatcher is shared with the UIs of each builder
+	dispatcher *dispatcher
 }
 
-// New creates a UI and passes the callers
-func New(callers ...CallerFunc) packer.Ui {
-	_callers := make(Callers)
+// New creates a UI for build id and passes the callers
+func New(id string, callers ...CallerFunc) *UI {
+	ui := &UI{
+		ID:         id,
+		dispatcher: &dispatcher{id: id},
+	}
 
 	for _, c := range callers {
-		c(_callers)
+		c(ui)
 	}
 
+	return ui
+}
+
+// ForBuilder returns a UI that tags messages with the builder they came
+// from, sharing the callers and sequence of ui
+func (ui *UI) ForBuilder(name string) packer.Ui {
 	return &UI{
-		Callers: _callers,
+		ID:         ui.ID,
+		Builder:    name,
+		dispatcher: ui.dispatcher,
 	}
 }
 
+// Close waits for every caller to be handed the messages queued for it.
+// Messages written after Close are dropped. It closes the UIs of every
+// builder too
+func (ui *UI) Close() {
+	ui.dispatcher.close()
+}
+
+// Stats returns what happened to the messages of each caller
+func (ui *UI) Stats() map[string]CallerStats {
+	return ui.dispatcher.stats()
+}
+
 // Ask a for information
 func (ui *UI) Ask(prompt string) (string, error) {
-	ui.call(callerTypeAsk, prompt)
+	ui.call(callerTypeAsk, prompt, nil)
 
 	return "", fmt.Errorf("This isn't implemented")
 }
 
 // Say func
 func (ui *UI) Say(message string) {
-	ui.call(callerTypeSay, message)
+	ui.call(callerTypeSay, message, nil)
 }
 
 // Message func
 func (ui *UI) Message(message string) {
-	ui.call(callerTypeMessage, message)
+	ui.call(callerTypeMessage, message, nil)
 }
 
 // Error func
 func (ui *UI) Error(message string) {
-	ui.call(callerTypeError, message)
+	ui.call(callerTypeError, message, nil)
 }
 
-// Machine func
+// Machine func, the message keeps the structured fields. Like packer,
+// the target is the builder the message came from
 func (ui *UI) Machine(t string, args ...string) {
-	ui.call(callerTypeMachine, fmt.Sprintf("%s: %#v", t, args))
+	ui.call(callerTypeMachine, fmt.Sprintf("%s: %s", t, strings.Join(args, " ")), &MachineMessage{
+		Type:   t,
+		Target: ui.Builder,
+		Data:   args,
+	})
 }
 
-func (ui *UI) call(ct callerType, message string) {
-	for n, c := range ui.Callers {
-		log.Debugf("Calling %q: %s - %s", n, ct.String(), message)
-		c.Call(&Message{
-			Type:    ct,
-			Message: message,
-		})
-	}
+func (ui *UI) call(ct callerType, message string, machine *MachineMessage) {
+	ui.dispatcher.dispatch(Message{
+		Date:    time.Now(),
+		ID:      ui.ID,
+		Builder: ui.Builder,
+		Message: message,
+		Type:    ct,
+		Machine: machine,
+	})
 }
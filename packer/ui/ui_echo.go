@@ -8,6 +8,7 @@ import (
 type EchoCaller struct{}
 
 // Call does something with the message
-func (ec *EchoCaller) Call(msg *Message) {
-	fmt.Printf("%s: %s", msg.Type.String(), msg.Message)
+func (ec *EchoCaller) Call(msg *Message) error {
+	_, err := fmt.Printf("[%s] %s: %s\n", msg.ID, msg.Type.String(), msg.Message)
+	return err
 }
@@ -1,36 +1,35 @@
 package ui
 
 import (
-	"bytes"
 	"encoding/json"
-	"time"
 
 	"github.com/hailocab/bakery-service/elastic"
 )
 
-// ElasticCaller elastic search caller
+// ElasticCaller elastic search caller, messages are indexed in bulk
 type ElasticCaller struct {
-	ID      string
-	Elastic *elastic.Elastic
+	Writer *elastic.BulkWriter
 }
 
 // NewElasticCaller creates a new elastic caller
-func NewElasticCaller(id string, e *elastic.Elastic) *ElasticCaller {
+func NewElasticCaller(e *elastic.Elastic) *ElasticCaller {
 	return &ElasticCaller{
-		ID:      id,
-		Elastic: e,
+		Writer: e.NewBulkWriter(),
 	}
 }
 
-// Call writes msg to elastic search
-func (ec *ElasticCaller) Call(msg *Message) {
-	msg.ID = ec.ID
-	msg.Date = time.Now()
-
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(msg); err != nil {
-		return
+// Call queues msg to be written to elastic search
+func (ec *ElasticCaller) Call(msg *Message) error {
+	doc, err := json.Marshal(msg)
+	if err != nil {
+		return err
 	}
 
-	ec.Elastic.Write(buf.String())
+	return ec.Writer.Write(string(doc))
+}
+
+// Close writes the messages still queued, it errors if any message
+// couldn't be indexed
+func (ec *ElasticCaller) Close() error {
+	return ec.Writer.Close()
 }
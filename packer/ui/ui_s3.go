@@ -3,66 +3,128 @@ package ui
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/hailocab/bakery-service/aws"
 	"github.com/hailocab/bakery-service/packer/util"
-)
+	"github.com/hailocab/bakery-service/storage"
 
-const (
-	// BytesAllowedBeforeFlush marks the amount of bytes allowed to
-	// be written before being flushed
-	BytesAllowedBeforeFlush = 1024
+	log "github.com/cihub/seelog"
 )
 
-var (
-	// FlushTimeout is the amount time the buffer will flush
-	FlushTimeout = time.Second * 30
+const (
+	// MinPartSize is the smallest part S3 accepts in a multipart upload,
+	// other than the last one
+	MinPartSize = 5 * 1024 * 1024
 )
 
-// S3Caller a dummy caller
+// S3Caller archives messages to storage as JSON lines. The upload is only
+// started once the first part is written
 type S3Caller struct {
-	writer        *bufio.Writer
-	bucket        string
-	path          string
-	activeTimeout bool
+	sync.Mutex
+
+	store   storage.Store
+	key     string
+	upload  storage.Upload
+	counter *util.CallbackWriter
+	writer  *bufio.Writer
+	closed  bool
 }
 
-// NewS3Caller foo
-func NewS3Caller(bucket string, path string) *S3Caller {
-	w := util.CallbackWriter{
-		WriteCount: 1,
+// NewS3Caller creates a caller archiving to key in store
+func NewS3Caller(store storage.Store, key string) *S3Caller {
+	sc := &S3Caller{
+		store: store,
+		key:   key,
 	}
 
-	w.WriteFunc = func(p []byte) error {
-		return aws.UploadPart(bucket, path, w.WriteCount, bytes.NewReader(p))
+	sc.counter = &util.CallbackWriter{
+		WriteFunc: sc.writePart,
 	}
 
-	return &S3Caller{
-		writer: bufio.NewWriterSize(w, BytesAllowedBeforeFlush),
+	sc.writer = bufio.NewWriterSize(sc.counter, MinPartSize)
+
+	return sc
+}
+
+// Call buffers msg, writing a part once the buffer is full
+func (sc *S3Caller) Call(msg *Message) error {
+	sc.Lock()
+	defer sc.Unlock()
+
+	if sc.closed {
+		return fmt.Errorf("Log %q is already closed", sc.key)
 	}
+
+	m := *msg
+	if m.Date.IsZero() {
+		m.Date = time.Now()
+	}
+
+	line, err := json.Marshal(&m)
+	if err != nil {
+		return err
+	}
+
+	// Errors stick to the writer and are reported by Close too
+	_, err = sc.writer.Write(append(line, '\n'))
+	return err
 }
 
-// Call does something with the message
-func (sc *S3Caller) Call(msg *Message) {
-	sc.writer.WriteString(fmt.Sprintf("%s - %s: %s", time.Now(), msg.Type.String(), msg.Message))
+// Close writes the last part and completes the upload. If any part
+// failed to upload, the upload is aborted. A nil object is returned if
+// nothing was logged
+func (sc *S3Caller) Close() (*storage.Object, error) {
+	sc.Lock()
+	defer sc.Unlock()
+
+	if sc.closed {
+		return nil, fmt.Errorf("Log %q is already closed", sc.key)
+	}
 
-	// Ensure three's a timeout after a msg
-	go sc.timeout()
+	sc.closed = true
+
+	if err := sc.writer.Flush(); err != nil {
+		sc.abort()
+		return nil, fmt.Errorf("Unable to archive log %q: %v", sc.key, err)
+	}
+
+	if sc.upload == nil {
+		return nil, nil
+	}
+
+	log.Debugf("Completing log %q after %d parts", sc.key, sc.counter.WriteCount)
+
+	obj, err := sc.upload.Complete()
+	if err != nil {
+		sc.abort()
+		return nil, fmt.Errorf("Unable to archive log %q: %v", sc.key, err)
+	}
+
+	return obj, nil
 }
 
-func (sc *S3Caller) timeout() {
-	if sc.activeTimeout {
-		return
+func (sc *S3Caller) writePart(p []byte) error {
+	if sc.upload == nil {
+		upload, err := sc.store.Multipart(sc.key)
+		if err != nil {
+			return err
+		}
+
+		sc.upload = upload
 	}
 
-	sc.activeTimeout = true
+	return sc.upload.Append(bytes.NewReader(p))
+}
+
+func (sc *S3Caller) abort() {
+	if sc.upload == nil {
+		return
+	}
 
-	select {
-	case <-time.After(FlushTimeout):
-		// Flush buffer and force a write
-		sc.writer.Flush()
-		sc.activeTimeout = false
+	if err := sc.upload.Abort(); err != nil {
+		log.Errorf("Unable to abort log upload %q: %v", sc.key, err)
 	}
 }
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/hailocab/bakery-service/storage"
+)
+
+// fakeStore records multipart uploads in memory
+type fakeStore struct {
+	storage.Store
+	uploads []*fakeUpload
+	fail    bool
+}
+
+func (s *fakeStore) Multipart(key string) (storage.Upload, error) {
+	u := &fakeUpload{key: key, fail: s.fail}
+	s.uploads = append(s.uploads, u)
+
+	return u, nil
+}
+
+type fakeUpload struct {
+	key       string
+	parts     [][]byte
+	fail      bool
+	completed bool
+	aborted   bool
+}
+
+func (u *fakeUpload) Append(part io.ReadSeeker) error {
+	if u.fail {
+		return fmt.Errorf("Upload failed")
+	}
+
+	data, _ := ioutil.ReadAll(part)
+	u.parts = append(u.parts, data)
+
+	return nil
+}
+
+func (u *fakeUpload) Complete() (*storage.Object, error) {
+	u.completed = true
+	return &storage.Object{Key: u.key}, nil
+}
+
+func (u *fakeUpload) Abort() error {
+	u.aborted = true
+	return nil
+}
+
+func TestS3CallerParts(t *testing.T) {
+	store := &fakeStore{}
+	sc := NewS3Caller(store, "logs/abc.log")
+
+	big := strings.Repeat("x", MinPartSize/2)
+	for i := 0; i < 3; i++ {
+		sc.Call(&Message{Type: callerTypeSay, Message: big})
+	}
+
+	obj, err := sc.Close()
+	if err != nil || obj == nil || obj.Key != "logs/abc.log" {
+		t.Fatalf("Unexpected result closing %v: %v", obj, err)
+	}
+
+	if len(store.uploads) != 1 {
+		t.Fatalf("Expected 1 upload, got %d", len(store.uploads))
+	}
+
+	u := store.uploads[0]
+	if !u.completed || len(u.parts) != 2 {
+		t.Fatalf("Expected 2 parts to be completed, got %d", len(u.parts))
+	}
+
+	if len(u.parts[0]) < MinPartSize {
+		t.Errorf("Expected the first part to be at least %d bytes, got %d", MinPartSize, len(u.parts[0]))
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(bytes.Join(u.parts, nil)), []byte("\n"))
+	if len(lines) != 3 {
+		t.Errorf("Expected 3 lines, got %d", len(lines))
+	}
+
+	if sc.counter.WriteCount != 2 {
+		t.Errorf("Expected 2 writes to be counted, got %d", sc.counter.WriteCount)
+	}
+}
+
+func TestS3CallerEmpty(t *testing.T) {
+	store := &fakeStore{}
+	sc := NewS3Caller(store, "logs/abc.log")
+
+	obj, err := sc.Close()
+	if err != nil || obj != nil {
+		t.Fatalf("Expected no log, got %v: %v", obj, err)
+	}
+
+	if len(store.uploads) != 0 {
+		t.Errorf("Expected no upload to be started, got %d", len(store.uploads))
+	}
+}
+
+func TestS3CallerAbort(t *testing.T) {
+	store := &fakeStore{fail: true}
+	sc := NewS3Caller(store, "logs/abc.log")
+
+	sc.Call(&Message{Type: callerTypeError, Message: "boom"})
+
+	if _, err := sc.Close(); err == nil {
+		t.Fatal("Expected an error closing a failed upload")
+	}
+
+	if u := store.uploads[0]; !u.aborted || u.completed {
+		t.Error("Expected the upload to be aborted")
+	}
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"sync"
+	"testing"
+)
+
+// recordingCaller keeps every message it's called with
+type recordingCaller struct {
+	sync.Mutex
+	msgs []*Message
+}
+
+func (rc *recordingCaller) Call(msg *Message) error {
+	rc.Lock()
+	defer rc.Unlock()
+
+	rc.msgs = append(rc.msgs, msg)
+	return nil
+}
+
+func TestUIMethods(t *testing.T) {
+	rc := &recordingCaller{}
+	ui := New("abc", AddCaller("recorder", rc))
+
+	testCases := []struct {
+		call     func()
+		expected callerType
+		name     string
+	}{
+		{func() { ui.Ask("Continue?") }, callerTypeAsk, "Ask"},
+		{func() { ui.Say("Saying") }, callerTypeSay, "Say"},
+		{func() { ui.Message("Message") }, callerTypeMessage, "Message"},
+		{func() { ui.Error("Error") }, callerTypeError, "Error"},
+		{func() { ui.Machine("artifact-count", "1") }, callerTypeMachine, "Machine"},
+	}
+
+	for _, tc := range testCases {
+		tc.call()
+	}
+
+	ui.Close()
+
+	if len(rc.msgs) != len(testCases) {
+		t.Fatalf("Expected %d messages, got %d", len(testCases), len(rc.msgs))
+	}
+
+	for i, tc := range testCases {
+		msg := rc.msgs[i]
+		if msg.Type != tc.expected || msg.Type.String() != tc.name {
+			t.Errorf("%s: Unexpected type %d (%s)", tc.name, msg.Type, msg.Type.String())
+		}
+
+		if msg.ID != "abc" {
+			t.Errorf("%s: Expected build ID to be set, got %q", tc.name, msg.ID)
+		}
+
+		if msg.Sequence != uint64(i+1) {
+			t.Errorf("%s: Expected sequence %d, got %d", tc.name, i+1, msg.Sequence)
+		}
+
+		if msg.Date.IsZero() {
+			t.Errorf("%s: Expected date to be set", tc.name)
+		}
+	}
+}
+
+func TestAskIsNotImplemented(t *testing.T) {
+	ui := New("abc")
+
+	if _, err := ui.Ask("Continue?"); err == nil {
+		t.Fatal("Expected Ask to error")
+	}
+}
+
+func TestCallerTypeText(t *testing.T) {
+	for _, ct := range []callerType{callerTypeAsk, callerTypeSay, callerTypeMessage, callerTypeError, callerTypeMachine} {
+		text, err := ct.MarshalText()
+		if err != nil {
+			t.Fatalf("Unable to marshal %d: %v", ct, err)
+		}
+
+		var decoded callerType
+		if err := decoded.UnmarshalText(text); err != nil || decoded != ct {
+			t.Errorf("Expected %s to round trip, got %d: %v", text, decoded, err)
+		}
+	}
+
+	if ValidType("Unknown") {
+		t.Error("Expected unknown types to be invalid")
+	}
+
+	if callerType(0).String() != "Unknown" {
+		t.Errorf("Unexpected name for the zero type %q", callerType(0).String())
+	}
+}
+
+func TestForBuilder(t *testing.T) {
+	rc := &recordingCaller{}
+	ui := New("abc", AddCaller("recorder", rc))
+
+	ui.Say("Starting")
+	ui.ForBuilder("amazon-ebs").Say("Building")
+	ui.ForBuilder("docker").Error("Failed")
+	ui.Close()
+
+	if len(rc.msgs) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(rc.msgs))
+	}
+
+	for i, builder := range []string{"", "amazon-ebs", "docker"} {
+		msg := rc.msgs[i]
+		if msg.Builder != builder || msg.ID != "abc" {
+			t.Errorf("Expected message %d to be from %q, got %q", i, builder, msg.Builder)
+		}
+
+		// Builders share the build's sequence
+		if msg.Sequence != uint64(i+1) {
+			t.Errorf("Expected sequence %d, got %d", i+1, msg.Sequence)
+		}
+	}
+}
@@ -10,7 +10,7 @@ type CallbackWriter struct {
 }
 
 // Write func compat with io.Writer
-func (w CallbackWriter) Write(p []byte) (int, error) {
+func (w *CallbackWriter) Write(p []byte) (int, error) {
 	if err := w.WriteFunc(p); err != nil {
 		return 0, err
 	}
@@ -0,0 +1,118 @@
+package packer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mitchellh/packer/template"
+)
+
+// Validation is the outcome of checking a template without running it
+type Validation struct {
+	Errors                []string
+	MissingVariables      []string
+	UnknownBuilders       []string
+	UnknownProvisioners   []string
+	UnknownPostProcessors []string
+
+	// Builds holds the warnings and errors from preparing each build
+	Builds BuildResults
+}
+
+// Valid reports whether the template could be built
+func (v *Validation) Valid() bool {
+	if len(v.Errors) > 0 ||
+		len(v.MissingVariables) > 0 ||
+		len(v.UnknownBuilders) > 0 ||
+		len(v.UnknownProvisioners) > 0 ||
+		len(v.UnknownPostProcessors) > 0 {
+		return false
+	}
+
+	return len(v.Builds.Errors()) == 0
+}
+
+// Validate checks that the template can be built with variables. Every
+// build is prepared but never run. An error is only returned if the
+// validation itself couldn't be performed
+func (p *Packer) Validate(variables map[string]*Variable) (*Validation, error) {
+	config := NewConfig(PluginMinPort, PluginMaxPort)
+	if err := config.Discover(); err != nil {
+		return nil, fmt.Errorf("Unable to discover packer config: %v", err)
+	}
+
+	v := &Validation{
+		MissingVariables: MissingVariables(variables),
+	}
+
+	v.UnknownBuilders, v.UnknownProvisioners, v.UnknownPostProcessors = UnknownComponents(config, p.Template)
+
+	// Builds can't be created without their plugins or variables
+	if !v.Valid() {
+		return v, nil
+	}
+
+	core, err := p.newCore(config, variables)
+	if err != nil {
+		v.Errors = append(v.Errors, err.Error())
+		return v, nil
+	}
+
+	builds, err := p.ListBuilds(core)
+	if err != nil {
+		v.Errors = append(v.Errors, err.Error())
+		return v, nil
+	}
+
+	for _, b := range builds {
+		warnings, err := b.Prepare()
+
+		v.Builds = append(v.Builds, &BuildResult{
+			Name:     b.Name(),
+			Warnings: warnings,
+			Error:    err,
+		})
+	}
+
+	return v, nil
+}
+
+// UnknownComponents returns the builder, provisioner and post processor
+// types used by tpl that have no plugin in config
+func UnknownComponents(config *Config, tpl *template.Template) (builders []string, provisioners []string, postProcessors []string) {
+	for _, b := range tpl.Builders {
+		if _, ok := config.Builders[b.Type]; !ok {
+			builders = appendUnique(builders, b.Type)
+		}
+	}
+
+	for _, pr := range tpl.Provisioners {
+		if _, ok := config.Provisioners[pr.Type]; !ok {
+			provisioners = appendUnique(provisioners, pr.Type)
+		}
+	}
+
+	for _, seq := range tpl.PostProcessors {
+		for _, pp := range seq {
+			if _, ok := config.PostProcessors[pp.Type]; !ok {
+				postProcessors = appendUnique(postProcessors, pp.Type)
+			}
+		}
+	}
+
+	sort.Strings(builders)
+	sort.Strings(provisioners)
+	sort.Strings(postProcessors)
+
+	return builders, provisioners, postProcessors
+}
+
+func appendUnique(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+
+	return append(s, v)
+}
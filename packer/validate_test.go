@@ -0,0 +1,58 @@
+package packer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/packer/template"
+)
+
+func TestUnknownComponents(t *testing.T) {
+	config := NewConfig(1, 2)
+	config.Builders["amazon-ebs"] = "/usr/local/bin/packer-builder-amazon-ebs"
+	config.Provisioners = map[string]string{"shell": "/usr/local/bin/packer-provisioner-shell"}
+
+	tpl := &template.Template{
+		Builders: map[string]*template.Builder{
+			"amazon-ebs": {Name: "amazon-ebs", Type: "amazon-ebs"},
+			"docker":     {Name: "docker", Type: "docker"},
+			"docker-2":   {Name: "docker-2", Type: "docker"},
+		},
+		Provisioners: []*template.Provisioner{
+			{Type: "shell"},
+			{Type: "chef-solo"},
+		},
+		PostProcessors: [][]*template.PostProcessor{
+			{{Type: "compress"}},
+		},
+	}
+
+	builders, provisioners, postProcessors := UnknownComponents(config, tpl)
+
+	if !reflect.DeepEqual(builders, []string{"docker"}) {
+		t.Errorf("Unexpected unknown builders: %v", builders)
+	}
+
+	if !reflect.DeepEqual(provisioners, []string{"chef-solo"}) {
+		t.Errorf("Unexpected unknown provisioners: %v", provisioners)
+	}
+
+	if !reflect.DeepEqual(postProcessors, []string{"compress"}) {
+		t.Errorf("Unexpected unknown post processors: %v", postProcessors)
+	}
+}
+
+func TestValidationValid(t *testing.T) {
+	v := &Validation{
+		Builds: BuildResults{{Name: "amazon-ebs", Warnings: []string{"careful"}}},
+	}
+
+	if !v.Valid() {
+		t.Fatal("Warnings shouldn't invalidate a template")
+	}
+
+	v.MissingVariables = []string{"region"}
+	if v.Valid() {
+		t.Fatal("Missing variables should invalidate a template")
+	}
+}
@@ -0,0 +1,137 @@
+// Code generated by protoc-gen-go.
+// source: github.com/hailocab/bakery-service/proto/artifacts/artifacts.proto
+// DO NOT EDIT!
+
+/*
+Package com_hailocab_service_bakery_artifacts is a generated protocol buffer package.
+
+It is generated from these files:
+	github.com/hailocab/bakery-service/proto/artifacts/artifacts.proto
+
+It has these top-level messages:
+	Request
+	Response
+	Artifact
+*/
+package com_hailocab_service_bakery_artifacts
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type Request struct {
+	Id               *string `protobuf:"bytes,1,req,name=id" json:"id,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+
+func (m *Request) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+type Response struct {
+	Id               *string     `protobuf:"bytes,1,req,name=id" json:"id,omitempty"`
+	State            *string     `protobuf:"bytes,2,req,name=state" json:"state,omitempty"`
+	Artifacts        []*Artifact `protobuf:"bytes,3,rep,name=artifacts" json:"artifacts,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+
+func (m *Response) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Response) GetState() string {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ""
+}
+
+func (m *Response) GetArtifacts() []*Artifact {
+	if m != nil {
+		return m.Artifacts
+	}
+	return nil
+}
+
+type Artifact struct {
+	Builder          *string  `protobuf:"bytes,1,req,name=builder" json:"builder,omitempty"`
+	BuilderId        *string  `protobuf:"bytes,2,req,name=builder_id" json:"builder_id,omitempty"`
+	Id               *string  `protobuf:"bytes,3,req,name=id" json:"id,omitempty"`
+	Region           *string  `protobuf:"bytes,4,opt,name=region" json:"region,omitempty"`
+	Ami              *string  `protobuf:"bytes,5,opt,name=ami" json:"ami,omitempty"`
+	Files            []string `protobuf:"bytes,6,rep,name=files" json:"files,omitempty"`
+	Description      *string  `protobuf:"bytes,7,opt,name=description" json:"description,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Artifact) Reset()         { *m = Artifact{} }
+func (m *Artifact) String() string { return proto.CompactTextString(m) }
+func (*Artifact) ProtoMessage()    {}
+
+func (m *Artifact) GetBuilder() string {
+	if m != nil && m.Builder != nil {
+		return *m.Builder
+	}
+	return ""
+}
+
+func (m *Artifact) GetBuilderId() string {
+	if m != nil && m.BuilderId != nil {
+		return *m.BuilderId
+	}
+	return ""
+}
+
+func (m *Artifact) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Artifact) GetRegion() string {
+	if m != nil && m.Region != nil {
+		return *m.Region
+	}
+	return ""
+}
+
+func (m *Artifact) GetAmi() string {
+	if m != nil && m.Ami != nil {
+		return *m.Ami
+	}
+	return ""
+}
+
+func (m *Artifact) GetFiles() []string {
+	if m != nil {
+		return m.Files
+	}
+	return nil
+}
+
+func (m *Artifact) GetDescription() string {
+	if m != nil && m.Description != nil {
+		return *m.Description
+	}
+	return ""
+}
@@ -27,6 +27,11 @@ var _ = math.Inf
 type Request struct {
 	Template         *string     `protobuf:"bytes,1,req,name=template" json:"template,omitempty"`
 	Variables        []*Variable `protobuf:"bytes,2,rep,name=variables" json:"variables,omitempty"`
+	Priority         *int32      `protobuf:"varint,3,opt,name=priority" json:"priority,omitempty"`
+	Format           *string     `protobuf:"bytes,4,opt,name=format" json:"format,omitempty"`
+	Version          *string     `protobuf:"bytes,5,opt,name=version" json:"version,omitempty"`
+	AccountId        *string     `protobuf:"bytes,6,opt,name=account_id" json:"account_id,omitempty"`
+	Regions          []string    `protobuf:"bytes,7,rep,name=regions" json:"regions,omitempty"`
 	XXX_unrecognized []byte      `json:"-"`
 }
 
@@ -48,8 +53,44 @@ func (m *Request) GetVariables() []*Variable {
 	return nil
 }
 
+func (m *Request) GetPriority() int32 {
+	if m != nil && m.Priority != nil {
+		return *m.Priority
+	}
+	return 0
+}
+
+func (m *Request) GetFormat() string {
+	if m != nil && m.Format != nil {
+		return *m.Format
+	}
+	return ""
+}
+
+func (m *Request) GetVersion() string {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return ""
+}
+
+func (m *Request) GetAccountId() string {
+	if m != nil && m.AccountId != nil {
+		return *m.AccountId
+	}
+	return ""
+}
+
+func (m *Request) GetRegions() []string {
+	if m != nil {
+		return m.Regions
+	}
+	return nil
+}
+
 type Response struct {
 	Id               *string `protobuf:"bytes,1,req,name=id" json:"id,omitempty"`
+	Position         *int32  `protobuf:"varint,2,opt,name=position" json:"position,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -64,6 +105,13 @@ func (m *Response) GetId() string {
 	return ""
 }
 
+func (m *Response) GetPosition() int32 {
+	if m != nil && m.Position != nil {
+		return *m.Position
+	}
+	return 0
+}
+
 type Variable struct {
 	Key              *string `protobuf:"bytes,1,req,name=key" json:"key,omitempty"`
 	Value            *string `protobuf:"bytes,2,req,name=value" json:"value,omitempty"`
@@ -0,0 +1,64 @@
+// Code generated by protoc-gen-go.
+// source: github.com/hailocab/bakery-service/proto/cancel/cancel.proto
+// DO NOT EDIT!
+
+/*
+Package com_hailocab_service_bakery_cancel is a generated protocol buffer package.
+
+It is generated from these files:
+	github.com/hailocab/bakery-service/proto/cancel/cancel.proto
+
+It has these top-level messages:
+	Request
+	Response
+*/
+package com_hailocab_service_bakery_cancel
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type Request struct {
+	Id               *string `protobuf:"bytes,1,req,name=id" json:"id,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+
+func (m *Request) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+type Response struct {
+	Id               *string `protobuf:"bytes,1,req,name=id" json:"id,omitempty"`
+	State            *string `protobuf:"bytes,2,req,name=state" json:"state,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+
+func (m *Response) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Response) GetState() string {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ""
+}
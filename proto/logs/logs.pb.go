@@ -0,0 +1,145 @@
+// Code generated by protoc-gen-go.
+// source: github.com/hailocab/bakery-service/proto/logs/logs.proto
+// DO NOT EDIT!
+
+/*
+Package com_hailocab_service_bakery_logs is a generated protocol buffer package.
+
+It is generated from these files:
+	github.com/hailocab/bakery-service/proto/logs/logs.proto
+
+It has these top-level messages:
+	Request
+	Response
+	Message
+*/
+package com_hailocab_service_bakery_logs
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type Request struct {
+	Id               *string  `protobuf:"bytes,1,req,name=id" json:"id,omitempty"`
+	Offset           *int32   `protobuf:"varint,2,opt,name=offset" json:"offset,omitempty"`
+	Limit            *int32   `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+	Types            []string `protobuf:"bytes,4,rep,name=types" json:"types,omitempty"`
+	Since            *string  `protobuf:"bytes,5,opt,name=since" json:"since,omitempty"`
+	Builder          *string  `protobuf:"bytes,6,opt,name=builder" json:"builder,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+
+func (m *Request) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Request) GetOffset() int32 {
+	if m != nil && m.Offset != nil {
+		return *m.Offset
+	}
+	return 0
+}
+
+func (m *Request) GetLimit() int32 {
+	if m != nil && m.Limit != nil {
+		return *m.Limit
+	}
+	return 0
+}
+
+func (m *Request) GetTypes() []string {
+	if m != nil {
+		return m.Types
+	}
+	return nil
+}
+
+func (m *Request) GetSince() string {
+	if m != nil && m.Since != nil {
+		return *m.Since
+	}
+	return ""
+}
+
+func (m *Request) GetBuilder() string {
+	if m != nil && m.Builder != nil {
+		return *m.Builder
+	}
+	return ""
+}
+
+type Response struct {
+	Messages         []*Message `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
+	Cursor           *string    `protobuf:"bytes,2,opt,name=cursor" json:"cursor,omitempty"`
+	XXX_unrecognized []byte     `json:"-"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+
+func (m *Response) GetMessages() []*Message {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+func (m *Response) GetCursor() string {
+	if m != nil && m.Cursor != nil {
+		return *m.Cursor
+	}
+	return ""
+}
+
+type Message struct {
+	Date             *string `protobuf:"bytes,1,req,name=date" json:"date,omitempty"`
+	Type             *string `protobuf:"bytes,2,req,name=type" json:"type,omitempty"`
+	Message          *string `protobuf:"bytes,3,req,name=message" json:"message,omitempty"`
+	Builder          *string `protobuf:"bytes,4,opt,name=builder" json:"builder,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Message) Reset()         { *m = Message{} }
+func (m *Message) String() string { return proto.CompactTextString(m) }
+func (*Message) ProtoMessage()    {}
+
+func (m *Message) GetDate() string {
+	if m != nil && m.Date != nil {
+		return *m.Date
+	}
+	return ""
+}
+
+func (m *Message) GetType() string {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return ""
+}
+
+func (m *Message) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+func (m *Message) GetBuilder() string {
+	if m != nil && m.Builder != nil {
+		return *m.Builder
+	}
+	return ""
+}
@@ -0,0 +1,259 @@
+// Code generated by protoc-gen-go.
+// source: github.com/hailocab/bakery-service/proto/status/status.proto
+// DO NOT EDIT!
+
+/*
+Package com_hailocab_service_bakery_status is a generated protocol buffer package.
+
+It is generated from these files:
+	github.com/hailocab/bakery-service/proto/status/status.proto
+
+It has these top-level messages:
+	Request
+	Response
+	TemplateSource
+	BuilderError
+	Artifact
+*/
+package com_hailocab_service_bakery_status
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type Request struct {
+	Id               *string `protobuf:"bytes,1,req,name=id" json:"id,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+
+func (m *Request) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+type Response struct {
+	Id               *string         `protobuf:"bytes,1,req,name=id" json:"id,omitempty"`
+	Template         *string         `protobuf:"bytes,2,req,name=template" json:"template,omitempty"`
+	State            *string         `protobuf:"bytes,3,req,name=state" json:"state,omitempty"`
+	Created          *int64          `protobuf:"varint,4,opt,name=created" json:"created,omitempty"`
+	Started          *int64          `protobuf:"varint,5,opt,name=started" json:"started,omitempty"`
+	Ended            *int64          `protobuf:"varint,6,opt,name=ended" json:"ended,omitempty"`
+	Errors           []*BuilderError `protobuf:"bytes,7,rep,name=errors" json:"errors,omitempty"`
+	Artifacts        []*Artifact     `protobuf:"bytes,8,rep,name=artifacts" json:"artifacts,omitempty"`
+	WorkspaceBytes   *int64          `protobuf:"varint,9,opt,name=workspace_bytes" json:"workspace_bytes,omitempty"`
+	Source           *TemplateSource `protobuf:"bytes,10,opt,name=source" json:"source,omitempty"`
+	AccountId        *string         `protobuf:"bytes,11,opt,name=account_id" json:"account_id,omitempty"`
+	Regions          []string        `protobuf:"bytes,12,rep,name=regions" json:"regions,omitempty"`
+	LogUrl           *string         `protobuf:"bytes,13,opt,name=log_url" json:"log_url,omitempty"`
+	LogErrors        []string        `protobuf:"bytes,14,rep,name=log_errors" json:"log_errors,omitempty"`
+	XXX_unrecognized []byte          `json:"-"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+
+func (m *Response) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Response) GetTemplate() string {
+	if m != nil && m.Template != nil {
+		return *m.Template
+	}
+	return ""
+}
+
+func (m *Response) GetState() string {
+	if m != nil && m.State != nil {
+		return *m.State
+	}
+	return ""
+}
+
+func (m *Response) GetCreated() int64 {
+	if m != nil && m.Created != nil {
+		return *m.Created
+	}
+	return 0
+}
+
+func (m *Response) GetStarted() int64 {
+	if m != nil && m.Started != nil {
+		return *m.Started
+	}
+	return 0
+}
+
+func (m *Response) GetEnded() int64 {
+	if m != nil && m.Ended != nil {
+		return *m.Ended
+	}
+	return 0
+}
+
+func (m *Response) GetErrors() []*BuilderError {
+	if m != nil {
+		return m.Errors
+	}
+	return nil
+}
+
+func (m *Response) GetArtifacts() []*Artifact {
+	if m != nil {
+		return m.Artifacts
+	}
+	return nil
+}
+
+func (m *Response) GetWorkspaceBytes() int64 {
+	if m != nil && m.WorkspaceBytes != nil {
+		return *m.WorkspaceBytes
+	}
+	return 0
+}
+
+func (m *Response) GetSource() *TemplateSource {
+	if m != nil {
+		return m.Source
+	}
+	return nil
+}
+
+func (m *Response) GetAccountId() string {
+	if m != nil && m.AccountId != nil {
+		return *m.AccountId
+	}
+	return ""
+}
+
+func (m *Response) GetRegions() []string {
+	if m != nil {
+		return m.Regions
+	}
+	return nil
+}
+
+func (m *Response) GetLogUrl() string {
+	if m != nil && m.LogUrl != nil {
+		return *m.LogUrl
+	}
+	return ""
+}
+
+func (m *Response) GetLogErrors() []string {
+	if m != nil {
+		return m.LogErrors
+	}
+	return nil
+}
+
+type TemplateSource struct {
+	Key              *string `protobuf:"bytes,1,req,name=key" json:"key,omitempty"`
+	Version          *string `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
+	Etag             *string `protobuf:"bytes,3,opt,name=etag" json:"etag,omitempty"`
+	Checksum         *string `protobuf:"bytes,4,opt,name=checksum" json:"checksum,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *TemplateSource) Reset()         { *m = TemplateSource{} }
+func (m *TemplateSource) String() string { return proto.CompactTextString(m) }
+func (*TemplateSource) ProtoMessage()    {}
+
+func (m *TemplateSource) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *TemplateSource) GetVersion() string {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return ""
+}
+
+func (m *TemplateSource) GetEtag() string {
+	if m != nil && m.Etag != nil {
+		return *m.Etag
+	}
+	return ""
+}
+
+func (m *TemplateSource) GetChecksum() string {
+	if m != nil && m.Checksum != nil {
+		return *m.Checksum
+	}
+	return ""
+}
+
+type BuilderError struct {
+	Builder          *string `protobuf:"bytes,1,req,name=builder" json:"builder,omitempty"`
+	Error            *string `protobuf:"bytes,2,req,name=error" json:"error,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *BuilderError) Reset()         { *m = BuilderError{} }
+func (m *BuilderError) String() string { return proto.CompactTextString(m) }
+func (*BuilderError) ProtoMessage()    {}
+
+func (m *BuilderError) GetBuilder() string {
+	if m != nil && m.Builder != nil {
+		return *m.Builder
+	}
+	return ""
+}
+
+func (m *BuilderError) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
+
+type Artifact struct {
+	Builder          *string `protobuf:"bytes,1,req,name=builder" json:"builder,omitempty"`
+	Id               *string `protobuf:"bytes,2,req,name=id" json:"id,omitempty"`
+	Description      *string `protobuf:"bytes,3,opt,name=description" json:"description,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Artifact) Reset()         { *m = Artifact{} }
+func (m *Artifact) String() string { return proto.CompactTextString(m) }
+func (*Artifact) ProtoMessage()    {}
+
+func (m *Artifact) GetBuilder() string {
+	if m != nil && m.Builder != nil {
+		return *m.Builder
+	}
+	return ""
+}
+
+func (m *Artifact) GetId() string {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return ""
+}
+
+func (m *Artifact) GetDescription() string {
+	if m != nil && m.Description != nil {
+		return *m.Description
+	}
+	return ""
+}
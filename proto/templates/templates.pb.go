@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-go.
+// source: github.com/hailocab/bakery-service/proto/templates/templates.proto
+// DO NOT EDIT!
+
+/*
+Package com_hailocab_service_bakery_templates is a generated protocol buffer package.
+
+It is generated from these files:
+	github.com/hailocab/bakery-service/proto/templates/templates.proto
+
+It has these top-level messages:
+	ListRequest
+	ListResponse
+	Template
+	DescribeRequest
+	DescribeResponse
+	Builder
+	Variable
+*/
+package com_hailocab_service_bakery_templates
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type ListRequest struct {
+	Prefix           *string `protobuf:"bytes,1,opt,name=prefix" json:"prefix,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ListRequest) Reset()         { *m = ListRequest{} }
+func (m *ListRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRequest) ProtoMessage()    {}
+
+func (m *ListRequest) GetPrefix() string {
+	if m != nil && m.Prefix != nil {
+		return *m.Prefix
+	}
+	return ""
+}
+
+type ListResponse struct {
+	Templates        []*Template `protobuf:"bytes,1,rep,name=templates" json:"templates,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *ListResponse) Reset()         { *m = ListResponse{} }
+func (m *ListResponse) String() string { return proto.CompactTextString(m) }
+func (*ListResponse) ProtoMessage()    {}
+
+func (m *ListResponse) GetTemplates() []*Template {
+	if m != nil {
+		return m.Templates
+	}
+	return nil
+}
+
+type Template struct {
+	Name             *string `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Key              *string `protobuf:"bytes,2,req,name=key" json:"key,omitempty"`
+	Format           *string `protobuf:"bytes,3,req,name=format" json:"format,omitempty"`
+	Version          *string `protobuf:"bytes,4,opt,name=version" json:"version,omitempty"`
+	Size             *int64  `protobuf:"varint,5,opt,name=size" json:"size,omitempty"`
+	LastModified     *int64  `protobuf:"varint,6,opt,name=last_modified" json:"last_modified,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Template) Reset()         { *m = Template{} }
+func (m *Template) String() string { return proto.CompactTextString(m) }
+func (*Template) ProtoMessage()    {}
+
+func (m *Template) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *Template) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *Template) GetFormat() string {
+	if m != nil && m.Format != nil {
+		return *m.Format
+	}
+	return ""
+}
+
+func (m *Template) GetVersion() string {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return ""
+}
+
+func (m *Template) GetSize() int64 {
+	if m != nil && m.Size != nil {
+		return *m.Size
+	}
+	return 0
+}
+
+func (m *Template) GetLastModified() int64 {
+	if m != nil && m.LastModified != nil {
+		return *m.LastModified
+	}
+	return 0
+}
+
+type DescribeRequest struct {
+	Template         *string `protobuf:"bytes,1,req,name=template" json:"template,omitempty"`
+	Format           *string `protobuf:"bytes,2,opt,name=format" json:"format,omitempty"`
+	Version          *string `protobuf:"bytes,3,opt,name=version" json:"version,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DescribeRequest) Reset()         { *m = DescribeRequest{} }
+func (m *DescribeRequest) String() string { return proto.CompactTextString(m) }
+func (*DescribeRequest) ProtoMessage()    {}
+
+func (m *DescribeRequest) GetTemplate() string {
+	if m != nil && m.Template != nil {
+		return *m.Template
+	}
+	return ""
+}
+
+func (m *DescribeRequest) GetFormat() string {
+	if m != nil && m.Format != nil {
+		return *m.Format
+	}
+	return ""
+}
+
+func (m *DescribeRequest) GetVersion() string {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return ""
+}
+
+type DescribeResponse struct {
+	Template         *string     `protobuf:"bytes,1,req,name=template" json:"template,omitempty"`
+	Key              *string     `protobuf:"bytes,2,req,name=key" json:"key,omitempty"`
+	Version          *string     `protobuf:"bytes,3,opt,name=version" json:"version,omitempty"`
+	Description      *string     `protobuf:"bytes,4,opt,name=description" json:"description,omitempty"`
+	Builders         []*Builder  `protobuf:"bytes,5,rep,name=builders" json:"builders,omitempty"`
+	Provisioners     []string    `protobuf:"bytes,6,rep,name=provisioners" json:"provisioners,omitempty"`
+	PostProcessors   []string    `protobuf:"bytes,7,rep,name=post_processors" json:"post_processors,omitempty"`
+	Variables        []*Variable `protobuf:"bytes,8,rep,name=variables" json:"variables,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *DescribeResponse) Reset()         { *m = DescribeResponse{} }
+func (m *DescribeResponse) String() string { return proto.CompactTextString(m) }
+func (*DescribeResponse) ProtoMessage()    {}
+
+func (m *DescribeResponse) GetTemplate() string {
+	if m != nil && m.Template != nil {
+		return *m.Template
+	}
+	return ""
+}
+
+func (m *DescribeResponse) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *DescribeResponse) GetVersion() string {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return ""
+}
+
+func (m *DescribeResponse) GetDescription() string {
+	if m != nil && m.Description != nil {
+		return *m.Description
+	}
+	return ""
+}
+
+func (m *DescribeResponse) GetBuilders() []*Builder {
+	if m != nil {
+		return m.Builders
+	}
+	return nil
+}
+
+func (m *DescribeResponse) GetProvisioners() []string {
+	if m != nil {
+		return m.Provisioners
+	}
+	return nil
+}
+
+func (m *DescribeResponse) GetPostProcessors() []string {
+	if m != nil {
+		return m.PostProcessors
+	}
+	return nil
+}
+
+func (m *DescribeResponse) GetVariables() []*Variable {
+	if m != nil {
+		return m.Variables
+	}
+	return nil
+}
+
+type Builder struct {
+	Name             *string `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Type             *string `protobuf:"bytes,2,req,name=type" json:"type,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Builder) Reset()         { *m = Builder{} }
+func (m *Builder) String() string { return proto.CompactTextString(m) }
+func (*Builder) ProtoMessage()    {}
+
+func (m *Builder) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *Builder) GetType() string {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return ""
+}
+
+type Variable struct {
+	Name             *string `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Required         *bool   `protobuf:"varint,2,req,name=required" json:"required,omitempty"`
+	Default          *string `protobuf:"bytes,3,opt,name=default" json:"default,omitempty"`
+	Automatic        *bool   `protobuf:"varint,4,req,name=automatic" json:"automatic,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Variable) Reset()         { *m = Variable{} }
+func (m *Variable) String() string { return proto.CompactTextString(m) }
+func (*Variable) ProtoMessage()    {}
+
+func (m *Variable) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *Variable) GetRequired() bool {
+	if m != nil && m.Required != nil {
+		return *m.Required
+	}
+	return false
+}
+
+func (m *Variable) GetDefault() string {
+	if m != nil && m.Default != nil {
+		return *m.Default
+	}
+	return ""
+}
+
+func (m *Variable) GetAutomatic() bool {
+	if m != nil && m.Automatic != nil {
+		return *m.Automatic
+	}
+	return false
+}
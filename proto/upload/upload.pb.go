@@ -0,0 +1,145 @@
+// Code generated by protoc-gen-go.
+// source: github.com/hailocab/bakery-service/proto/upload/upload.proto
+// DO NOT EDIT!
+
+/*
+Package com_hailocab_service_bakery_upload is a generated protocol buffer package.
+
+It is generated from these files:
+	github.com/hailocab/bakery-service/proto/upload/upload.proto
+
+It has these top-level messages:
+	Request
+	Response
+	ValidationError
+*/
+package com_hailocab_service_bakery_upload
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type Request struct {
+	Template         *string `protobuf:"bytes,1,req,name=template" json:"template,omitempty"`
+	Bundle           []byte  `protobuf:"bytes,2,opt,name=bundle" json:"bundle,omitempty"`
+	StagingKey       *string `protobuf:"bytes,3,opt,name=staging_key" json:"staging_key,omitempty"`
+	Format           *string `protobuf:"bytes,4,opt,name=format" json:"format,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+
+func (m *Request) GetTemplate() string {
+	if m != nil && m.Template != nil {
+		return *m.Template
+	}
+	return ""
+}
+
+func (m *Request) GetBundle() []byte {
+	if m != nil {
+		return m.Bundle
+	}
+	return nil
+}
+
+func (m *Request) GetStagingKey() string {
+	if m != nil && m.StagingKey != nil {
+		return *m.StagingKey
+	}
+	return ""
+}
+
+func (m *Request) GetFormat() string {
+	if m != nil && m.Format != nil {
+		return *m.Format
+	}
+	return ""
+}
+
+type Response struct {
+	Published        *bool              `protobuf:"varint,1,req,name=published" json:"published,omitempty"`
+	Key              *string            `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	Version          *string            `protobuf:"bytes,3,opt,name=version" json:"version,omitempty"`
+	Etag             *string            `protobuf:"bytes,4,opt,name=etag" json:"etag,omitempty"`
+	Errors           []*ValidationError `protobuf:"bytes,5,rep,name=errors" json:"errors,omitempty"`
+	XXX_unrecognized []byte             `json:"-"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+
+func (m *Response) GetPublished() bool {
+	if m != nil && m.Published != nil {
+		return *m.Published
+	}
+	return false
+}
+
+func (m *Response) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *Response) GetVersion() string {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return ""
+}
+
+func (m *Response) GetEtag() string {
+	if m != nil && m.Etag != nil {
+		return *m.Etag
+	}
+	return ""
+}
+
+func (m *Response) GetErrors() []*ValidationError {
+	if m != nil {
+		return m.Errors
+	}
+	return nil
+}
+
+type ValidationError struct {
+	Type             *string `protobuf:"bytes,1,req,name=type" json:"type,omitempty"`
+	Message          *string `protobuf:"bytes,2,req,name=message" json:"message,omitempty"`
+	Component        *string `protobuf:"bytes,3,opt,name=component" json:"component,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *ValidationError) Reset()         { *m = ValidationError{} }
+func (m *ValidationError) String() string { return proto.CompactTextString(m) }
+func (*ValidationError) ProtoMessage()    {}
+
+func (m *ValidationError) GetType() string {
+	if m != nil && m.Type != nil {
+		return *m.Type
+	}
+	return ""
+}
+
+func (m *ValidationError) GetMessage() string {
+	if m != nil && m.Message != nil {
+		return *m.Message
+	}
+	return ""
+}
+
+func (m *ValidationError) GetComponent() string {
+	if m != nil && m.Component != nil {
+		return *m.Component
+	}
+	return ""
+}
@@ -0,0 +1,210 @@
+// Code generated by protoc-gen-go.
+// source: github.com/hailocab/bakery-service/proto/validate/validate.proto
+// DO NOT EDIT!
+
+/*
+Package com_hailocab_service_bakery_validate is a generated protocol buffer package.
+
+It is generated from these files:
+	github.com/hailocab/bakery-service/proto/validate/validate.proto
+
+It has these top-level messages:
+	Request
+	Response
+	Variable
+	Build
+*/
+package com_hailocab_service_bakery_validate
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type Request struct {
+	Template         *string     `protobuf:"bytes,1,req,name=template" json:"template,omitempty"`
+	Variables        []*Variable `protobuf:"bytes,2,rep,name=variables" json:"variables,omitempty"`
+	Format           *string     `protobuf:"bytes,3,opt,name=format" json:"format,omitempty"`
+	Version          *string     `protobuf:"bytes,4,opt,name=version" json:"version,omitempty"`
+	AccountId        *string     `protobuf:"bytes,5,opt,name=account_id" json:"account_id,omitempty"`
+	Regions          []string    `protobuf:"bytes,6,rep,name=regions" json:"regions,omitempty"`
+	XXX_unrecognized []byte      `json:"-"`
+}
+
+func (m *Request) Reset()         { *m = Request{} }
+func (m *Request) String() string { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()    {}
+
+func (m *Request) GetTemplate() string {
+	if m != nil && m.Template != nil {
+		return *m.Template
+	}
+	return ""
+}
+
+func (m *Request) GetVariables() []*Variable {
+	if m != nil {
+		return m.Variables
+	}
+	return nil
+}
+
+func (m *Request) GetFormat() string {
+	if m != nil && m.Format != nil {
+		return *m.Format
+	}
+	return ""
+}
+
+func (m *Request) GetVersion() string {
+	if m != nil && m.Version != nil {
+		return *m.Version
+	}
+	return ""
+}
+
+func (m *Request) GetAccountId() string {
+	if m != nil && m.AccountId != nil {
+		return *m.AccountId
+	}
+	return ""
+}
+
+func (m *Request) GetRegions() []string {
+	if m != nil {
+		return m.Regions
+	}
+	return nil
+}
+
+type Response struct {
+	Valid                 *bool    `protobuf:"varint,1,req,name=valid" json:"valid,omitempty"`
+	Errors                []string `protobuf:"bytes,2,rep,name=errors" json:"errors,omitempty"`
+	MissingVariables      []string `protobuf:"bytes,3,rep,name=missing_variables" json:"missing_variables,omitempty"`
+	UnknownVariables      []string `protobuf:"bytes,4,rep,name=unknown_variables" json:"unknown_variables,omitempty"`
+	UnknownBuilders       []string `protobuf:"bytes,5,rep,name=unknown_builders" json:"unknown_builders,omitempty"`
+	UnknownProvisioners   []string `protobuf:"bytes,6,rep,name=unknown_provisioners" json:"unknown_provisioners,omitempty"`
+	UnknownPostProcessors []string `protobuf:"bytes,7,rep,name=unknown_post_processors" json:"unknown_post_processors,omitempty"`
+	Builds                []*Build `protobuf:"bytes,8,rep,name=builds" json:"builds,omitempty"`
+	XXX_unrecognized      []byte   `json:"-"`
+}
+
+func (m *Response) Reset()         { *m = Response{} }
+func (m *Response) String() string { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()    {}
+
+func (m *Response) GetValid() bool {
+	if m != nil && m.Valid != nil {
+		return *m.Valid
+	}
+	return false
+}
+
+func (m *Response) GetErrors() []string {
+	if m != nil {
+		return m.Errors
+	}
+	return nil
+}
+
+func (m *Response) GetMissingVariables() []string {
+	if m != nil {
+		return m.MissingVariables
+	}
+	return nil
+}
+
+func (m *Response) GetUnknownVariables() []string {
+	if m != nil {
+		return m.UnknownVariables
+	}
+	return nil
+}
+
+func (m *Response) GetUnknownBuilders() []string {
+	if m != nil {
+		return m.UnknownBuilders
+	}
+	return nil
+}
+
+func (m *Response) GetUnknownProvisioners() []string {
+	if m != nil {
+		return m.UnknownProvisioners
+	}
+	return nil
+}
+
+func (m *Response) GetUnknownPostProcessors() []string {
+	if m != nil {
+		return m.UnknownPostProcessors
+	}
+	return nil
+}
+
+func (m *Response) GetBuilds() []*Build {
+	if m != nil {
+		return m.Builds
+	}
+	return nil
+}
+
+type Variable struct {
+	Key              *string `protobuf:"bytes,1,req,name=key" json:"key,omitempty"`
+	Value            *string `protobuf:"bytes,2,req,name=value" json:"value,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *Variable) Reset()         { *m = Variable{} }
+func (m *Variable) String() string { return proto.CompactTextString(m) }
+func (*Variable) ProtoMessage()    {}
+
+func (m *Variable) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *Variable) GetValue() string {
+	if m != nil && m.Value != nil {
+		return *m.Value
+	}
+	return ""
+}
+
+type Build struct {
+	Name             *string  `protobuf:"bytes,1,req,name=name" json:"name,omitempty"`
+	Warnings         []string `protobuf:"bytes,2,rep,name=warnings" json:"warnings,omitempty"`
+	Error            *string  `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *Build) Reset()         { *m = Build{} }
+func (m *Build) String() string { return proto.CompactTextString(m) }
+func (*Build) ProtoMessage()    {}
+
+func (m *Build) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *Build) GetWarnings() []string {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
+func (m *Build) GetError() string {
+	if m != nil && m.Error != nil {
+		return *m.Error
+	}
+	return ""
+}
@@ -0,0 +1,231 @@
+package queue
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/hailocab/go-service-layer/config"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	// DefaultWorkers number of builds run concurrently if not configured
+	DefaultWorkers = 2
+
+	// DefaultMaxDepth number of builds allowed to wait if not configured
+	DefaultMaxDepth = 50
+)
+
+var (
+	// Default queue used by the handlers
+	Default *Queue
+
+	// ErrQueueFull is returned when the queue can't take any more jobs
+	ErrQueueFull = fmt.Errorf("Build queue is full")
+)
+
+// Job is a unit of work waiting in the queue
+type Job struct {
+	ID       string
+	Priority int
+	Run      func()
+
+	seq   uint64
+	index int
+}
+
+// Queue runs jobs on a fixed number of workers. Jobs with a higher
+// priority run first, jobs with the same priority run in FIFO order
+type Queue struct {
+	sync.Mutex
+
+	cond     *sync.Cond
+	jobs     jobHeap
+	maxDepth int
+	seq      uint64
+}
+
+// New creates a queue and starts its workers
+func New(workers int, maxDepth int) *Queue {
+	q := &Queue{
+		maxDepth: maxDepth,
+	}
+	q.cond = sync.NewCond(q)
+
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+
+	return q
+}
+
+// Push adds a job to the queue, returning its position. Position 1 is
+// the next job to run
+func (q *Queue) Push(job *Job) (int, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.jobs) >= q.maxDepth {
+		return 0, ErrQueueFull
+	}
+
+	q.seq++
+	job.seq = q.seq
+	heap.Push(&q.jobs, job)
+	q.cond.Signal()
+
+	return q.position(job), nil
+}
+
+// Position returns the position of a waiting job
+func (q *Queue) Position(id string) (int, bool) {
+	q.Lock()
+	defer q.Unlock()
+
+	for _, j := range q.jobs {
+		if j.ID == id {
+			return q.position(j), true
+		}
+	}
+
+	return 0, false
+}
+
+// Remove takes a waiting job off the queue
+func (q *Queue) Remove(id string) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	for _, j := range q.jobs {
+		if j.ID == id {
+			heap.Remove(&q.jobs, j.index)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Len returns the number of waiting jobs
+func (q *Queue) Len() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.jobs)
+}
+
+func (q *Queue) position(job *Job) int {
+	pos := 1
+	for _, j := range q.jobs {
+		if q.jobs.before(j, job) {
+			pos++
+		}
+	}
+
+	return pos
+}
+
+func (q *Queue) work() {
+	for {
+		q.Lock()
+		for len(q.jobs) == 0 {
+			q.cond.Wait()
+		}
+
+		job := heap.Pop(&q.jobs).(*Job)
+		q.Unlock()
+
+		log.Infof("Running job %q", job.ID)
+		job.Run()
+	}
+}
+
+type jobHeap []*Job
+
+func (h jobHeap) before(a, b *Job) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+
+	return a.seq < b.seq
+}
+
+func (h jobHeap) Len() int           { return len(h) }
+func (h jobHeap) Less(i, j int) bool { return h.before(h[i], h[j]) }
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	job := x.(*Job)
+	job.index = len(*h)
+	*h = append(*h, job)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	*h = old[:n-1]
+
+	return job
+}
+
+// Init loads config and starts the default queue
+func Init() {
+	conf, err := loadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	log.Infof("Starting build queue with %d workers and a max depth of %d", conf.Workers, conf.MaxDepth)
+
+	Default = New(conf.Workers, conf.MaxDepth)
+}
+
+func loadConfig() (*queueConfig, error) {
+	configJSON := config.AtPath(
+		"hailo", "service", "bakery", "queue",
+	).AsJson()
+
+	log.Debugf("Queue Config: %v", string(configJSON))
+
+	conf := queueConfig{
+		Workers:  DefaultWorkers,
+		MaxDepth: DefaultMaxDepth,
+	}
+
+	if err := json.Unmarshal(configJSON, &conf); err != nil {
+		return nil, err
+	}
+
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+type queueConfig struct {
+	Workers  int `json:"workers"`
+	MaxDepth int `json:"maxDepth"`
+}
+
+func (c *queueConfig) validate() error {
+	if c.Workers < 1 {
+		return fmt.Errorf("Queue needs at least one worker, got %d", c.Workers)
+	}
+
+	// Push rejects every build once the queue is this deep
+	if c.MaxDepth < 1 {
+		return fmt.Errorf("Queue needs a max depth of at least one, got %d", c.MaxDepth)
+	}
+
+	return nil
+}
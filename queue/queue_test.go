@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestOrdering(t *testing.T) {
+	// No workers, so nothing leaves the queue
+	q := New(0, 10)
+
+	positions := map[string]int{}
+	for _, j := range []*Job{
+		{ID: "a"},
+		{ID: "b"},
+		{ID: "urgent", Priority: 10},
+		{ID: "c"},
+	} {
+		pos, err := q.Push(j)
+		if err != nil {
+			t.Fatalf("Unable to push %q: %v", j.ID, err)
+		}
+
+		positions[j.ID] = pos
+	}
+
+	if positions["urgent"] != 1 {
+		t.Fatalf("Priority job should be first, got %d", positions["urgent"])
+	}
+
+	for id, expected := range map[string]int{"urgent": 1, "a": 2, "b": 3, "c": 4} {
+		if pos, _ := q.Position(id); pos != expected {
+			t.Fatalf("Expected %q at %d, got %d", id, expected, pos)
+		}
+	}
+
+	if !q.Remove("a") {
+		t.Fatal("Unable to remove waiting job")
+	}
+
+	if pos, _ := q.Position("c"); pos != 3 {
+		t.Fatalf("Expected c at 3 after removal, got %d", pos)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	q := New(0, 1)
+
+	if _, err := q.Push(&Job{ID: "a"}); err != nil {
+		t.Fatalf("Unable to push: %v", err)
+	}
+
+	if _, err := q.Push(&Job{ID: "b"}); err != ErrQueueFull {
+		t.Fatalf("Expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestWorkers(t *testing.T) {
+	q := New(2, 10)
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		ran  []string
+	)
+
+	for _, id := range []string{"a", "b", "c"} {
+		wg.Add(1)
+
+		id := id
+		q.Push(&Job{ID: id, Run: func() {
+			defer wg.Done()
+
+			lock.Lock()
+			ran = append(ran, id)
+			lock.Unlock()
+		}})
+	}
+
+	wg.Wait()
+
+	if len(ran) != 3 {
+		t.Fatalf("Expected 3 jobs to run, got %v", ran)
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	testCases := []struct {
+		conf  queueConfig
+		valid bool
+	}{
+		{queueConfig{Workers: 1, MaxDepth: 1}, true},
+		{queueConfig{Workers: 0, MaxDepth: 10}, false},
+		{queueConfig{Workers: 2, MaxDepth: 0}, false},
+		{queueConfig{Workers: 2, MaxDepth: -1}, false},
+	}
+
+	for _, tc := range testCases {
+		if err := tc.conf.validate(); (err == nil) != tc.valid {
+			t.Errorf("Expected %+v valid to be %v, got %v", tc.conf, tc.valid, err)
+		}
+	}
+}
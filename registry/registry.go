@@ -0,0 +1,334 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hailocab/go-service-layer/config"
+
+	log "github.com/cihub/seelog"
+)
+
+var (
+	// Default registry used by the handlers
+	Default *Registry
+
+	// ErrNotFound is returned when a build ID is unknown
+	ErrNotFound = fmt.Errorf("Build not found")
+
+	// ErrFinished is returned when cancelling a build that already ended
+	ErrFinished = fmt.Errorf("Build has already finished")
+
+	// ErrCancelled is returned when registering a canceller for a build
+	// that has been cancelled already
+	ErrCancelled = fmt.Errorf("Build has been cancelled")
+)
+
+// Registry keeps track of builds, optionally persisting them to disk
+type Registry struct {
+	sync.RWMutex
+
+	path       string
+	builds     map[string]*Build
+	cancellers map[string]func()
+}
+
+// New creates a registry. If path is set every build record is written
+// to it and existing records are loaded back
+func New(path string) (*Registry, error) {
+	r := &Registry{
+		path:       path,
+		builds:     map[string]*Build{},
+		cancellers: map[string]func(){},
+	}
+
+	if len(path) == 0 {
+		return r, nil
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("Unable to create registry path: %v", err)
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Create adds a new build in the queued state, baking into account and
+// regions
+func (r *Registry) Create(id string, template string, source Source, account string, regions []string) (*Build, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.builds[id]; ok {
+		return nil, fmt.Errorf("Build %q already exists", id)
+	}
+
+	b := &Build{
+		ID:       id,
+		Template: template,
+		Source:   source,
+		Account:  account,
+		Regions:  append([]string(nil), regions...),
+		State:    StateQueued,
+		Created:  time.Now(),
+		Errors:   map[string]string{},
+	}
+
+	r.builds[id] = b
+
+	if err := r.save(b); err != nil {
+		return nil, err
+	}
+
+	return b.copy(), nil
+}
+
+// Get returns a copy of a build
+func (r *Registry) Get(id string) (*Build, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	b, ok := r.builds[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return b.copy(), nil
+}
+
+// Update applies fn to a build and persists the result
+func (r *Registry) Update(id string, fn func(b *Build)) error {
+	r.Lock()
+	defer r.Unlock()
+
+	b, ok := r.builds[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	fn(b)
+
+	return r.save(b)
+}
+
+// Delete removes a build from the registry
+func (r *Registry) Delete(id string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.builds[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(r.builds, id)
+	delete(r.cancellers, id)
+
+	if len(r.path) == 0 {
+		return nil
+	}
+
+	if err := os.Remove(filepath.Join(r.path, id+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Unable to delete build %q: %v", id, err)
+	}
+
+	return nil
+}
+
+// SetState moves a build to a new state, recording start and end times
+func (r *Registry) SetState(id string, state State) error {
+	return r.Update(id, func(b *Build) {
+		b.setState(state)
+	})
+}
+
+// Finish records the outcome of a build
+func (r *Registry) Finish(id string, artifacts []*Artifact, errs map[string]error) error {
+	r.Lock()
+	delete(r.cancellers, id)
+	r.Unlock()
+
+	return r.Update(id, func(b *Build) {
+		b.addArtifacts(artifacts)
+		for n, err := range errs {
+			b.Errors[n] = err.Error()
+		}
+
+		switch {
+		case b.cancelRequested:
+			b.setState(StateCancelled)
+		case len(b.Errors) > 0:
+			b.setState(StateFailed)
+		default:
+			b.setState(StateSucceeded)
+		}
+	})
+}
+
+// AddArtifacts records artifacts of a build as they're built, artifacts
+// already recorded are skipped
+func (r *Registry) AddArtifacts(id string, artifacts []*Artifact) error {
+	return r.Update(id, func(b *Build) {
+		b.addArtifacts(artifacts)
+	})
+}
+
+// SetCanceller registers fn to stop a running build. It returns
+// ErrCancelled if the build was cancelled before fn could be registered
+func (r *Registry) SetCanceller(id string, fn func()) error {
+	r.Lock()
+	defer r.Unlock()
+
+	b, ok := r.builds[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if b.cancelRequested || b.State == StateCancelled {
+		return ErrCancelled
+	}
+
+	r.cancellers[id] = fn
+
+	return nil
+}
+
+// Cancel stops a build, waiting for its canceller to return. Cancelling
+// a cancelled build is a no-op
+func (r *Registry) Cancel(id string) (*Build, error) {
+	r.Lock()
+	b, ok := r.builds[id]
+	if !ok {
+		r.Unlock()
+		return nil, ErrNotFound
+	}
+
+	if b.State == StateCancelled {
+		r.Unlock()
+		return b.copy(), nil
+	}
+
+	if b.State.Finished() {
+		r.Unlock()
+		return nil, ErrFinished
+	}
+
+	b.cancelRequested = true
+	fn := r.cancellers[id]
+	r.Unlock()
+
+	if fn != nil {
+		log.Infof("Cancelling build %q", id)
+		fn()
+	}
+
+	if err := r.SetState(id, StateCancelled); err != nil {
+		return nil, err
+	}
+
+	return r.Get(id)
+}
+
+// Fail marks a build as failed with a single error
+func (r *Registry) Fail(id string, name string, err error) error {
+	return r.Finish(id, nil, map[string]error{name: err})
+}
+
+func (r *Registry) save(b *Build) error {
+	if len(r.path) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("Unable to encode build %q: %v", b.ID, err)
+	}
+
+	tmp := filepath.Join(r.path, b.ID+".json.tmp")
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("Unable to save build %q: %v", b.ID, err)
+	}
+
+	return os.Rename(tmp, filepath.Join(r.path, b.ID+".json"))
+}
+
+func (r *Registry) load() error {
+	files, err := filepath.Glob(filepath.Join(r.path, "*.json"))
+	if err != nil {
+		return fmt.Errorf("Unable to list registry: %v", err)
+	}
+
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("Unable to read %q: %v", f, err)
+		}
+
+		var b Build
+		if err := json.Unmarshal(data, &b); err != nil {
+			log.Errorf("Skipping corrupt build record %q: %v", f, err)
+			continue
+		}
+
+		if b.Errors == nil {
+			b.Errors = map[string]string{}
+		}
+
+		// Anything still in flight died with the previous process
+		if !b.State.Finished() {
+			b.Errors["bakery"] = "Build interrupted by a service restart"
+			b.setState(StateFailed)
+
+			if err := r.save(&b); err != nil {
+				return err
+			}
+		}
+
+		r.builds[strings.TrimSuffix(filepath.Base(f), ".json")] = &b
+	}
+
+	log.Infof("Loaded %d builds from %s", len(r.builds), r.path)
+
+	return nil
+}
+
+// Init loads config and sets up the default registry
+func Init() {
+	conf, err := loadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	Default, err = New(conf.Path)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func loadConfig() (*registryConfig, error) {
+	configJSON := config.AtPath(
+		"hailo", "service", "bakery", "registry",
+	).AsJson()
+
+	log.Debugf("Registry Config: %v", string(configJSON))
+
+	var conf registryConfig
+	if err := json.Unmarshal(configJSON, &conf); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+type registryConfig struct {
+	Path string `json:"path"`
+}
@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestBuildLifecycle(t *testing.T) {
+	r, err := New("")
+	if err != nil {
+		t.Fatalf("Unable to create registry: %v", err)
+	}
+
+	if _, err := r.Create("abc", "base", Source{}, "", nil); err != nil {
+		t.Fatalf("Unable to create build: %v", err)
+	}
+
+	if _, err := r.Create("abc", "base", Source{}, "", nil); err == nil {
+		t.Fatal("Duplicate build was accepted")
+	}
+
+	if err := r.SetState("abc", StatePreparing); err != nil {
+		t.Fatalf("Unable to set state: %v", err)
+	}
+
+	// Artifacts recorded while running aren't duplicated when finishing
+	if err := r.AddArtifacts("abc", []*Artifact{{Builder: "amazon-ebs", ID: "eu-west-1:ami-123"}}); err != nil {
+		t.Fatalf("Unable to add artifacts: %v", err)
+	}
+
+	err = r.Finish("abc", []*Artifact{{Builder: "amazon-ebs", ID: "eu-west-1:ami-123"}}, map[string]error{
+		"docker": fmt.Errorf("boom"),
+	})
+	if err != nil {
+		t.Fatalf("Unable to finish build: %v", err)
+	}
+
+	b, err := r.Get("abc")
+	if err != nil {
+		t.Fatalf("Unable to get build: %v", err)
+	}
+
+	if b.State != StateFailed {
+		t.Fatalf("Expected failed, got %s", b.State)
+	}
+
+	if b.Started.IsZero() || b.Ended.IsZero() {
+		t.Fatal("Start and end times not recorded")
+	}
+
+	if b.Errors["docker"] != "boom" || len(b.Artifacts) != 1 {
+		t.Fatalf("Result not recorded: %#v", b)
+	}
+
+	if _, err := r.Get("missing"); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := New(dir)
+	if err != nil {
+		t.Fatalf("Unable to create registry: %v", err)
+	}
+
+	r.Create("done", "base", Source{Key: "templates/base.zip", ETag: "abc"}, "123", []string{"eu-west-1", "us-east-1"})
+	r.Finish("done", nil, nil)
+	r.Create("running", "base", Source{}, "", nil)
+	r.SetState("running", StateRunning)
+
+	r, err = New(dir)
+	if err != nil {
+		t.Fatalf("Unable to reload registry: %v", err)
+	}
+
+	b, err := r.Get("done")
+	if err != nil || b.State != StateSucceeded || b.Source.ETag != "abc" {
+		t.Fatalf("Finished build not restored: %v %#v", err, b)
+	}
+
+	if b.Account != "123" || len(b.Regions) != 2 || b.Regions[1] != "us-east-1" {
+		t.Errorf("Expected the target to be saved with the build, got %q %v", b.Account, b.Regions)
+	}
+
+	b, err = r.Get("running")
+	if err != nil || b.State != StateFailed {
+		t.Fatalf("Interrupted build should be failed: %v %#v", err, b)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	r, _ := New("")
+
+	r.Create("running", "base", Source{}, "", nil)
+	r.SetState("running", StateRunning)
+
+	called := 0
+	if err := r.SetCanceller("running", func() { called++ }); err != nil {
+		t.Fatalf("Unable to set canceller: %v", err)
+	}
+
+	b, err := r.Cancel("running")
+	if err != nil || b.State != StateCancelled || called != 1 {
+		t.Fatalf("Build not cancelled: %v %#v", err, b)
+	}
+
+	// The runner finishing afterwards must not change the outcome
+	r.Finish("running", nil, map[string]error{"amazon-ebs": fmt.Errorf("interrupted")})
+
+	b, err = r.Cancel("running")
+	if err != nil || b.State != StateCancelled || called != 1 {
+		t.Fatalf("Cancel isn't idempotent: %v %#v", err, b)
+	}
+
+	r.Create("preparing", "base", Source{}, "", nil)
+	r.Cancel("preparing")
+
+	if err := r.SetCanceller("preparing", func() {}); err != ErrCancelled {
+		t.Fatalf("Expected ErrCancelled, got %v", err)
+	}
+
+	r.Create("done", "base", Source{Key: "templates/base.zip", ETag: "abc"}, "", nil)
+	r.Finish("done", nil, nil)
+
+	if _, err := r.Cancel("done"); err != ErrFinished {
+		t.Fatalf("Expected ErrFinished, got %v", err)
+	}
+
+	if _, err := r.Cancel("missing"); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, _ := New(dir)
+	r.Create("abc", "base", Source{}, "", nil)
+
+	if err := r.Delete("abc"); err != nil {
+		t.Fatalf("Unable to delete build: %v", err)
+	}
+
+	r, _ = New(dir)
+	if _, err := r.Get("abc"); err != ErrNotFound {
+		t.Fatalf("Deleted build was restored: %v", err)
+	}
+}
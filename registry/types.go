@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+)
+
+// State of a build
+type State int
+
+const (
+	// StateQueued build accepted but not started
+	StateQueued State = iota
+	// StatePreparing template and plugins are being set up
+	StatePreparing
+	// StateRunning builders are running
+	StateRunning
+	// StateSucceeded every builder finished without error
+	StateSucceeded
+	// StateFailed at least one builder errored
+	StateFailed
+	// StateCancelled build was cancelled by a caller
+	StateCancelled
+)
+
+var (
+	stateDescs = []string{
+		"queued",
+		"preparing",
+		"running",
+		"succeeded",
+		"failed",
+		"cancelled",
+	}
+)
+
+func (s State) String() string {
+	if int(s) < 0 || int(s) >= len(stateDescs) {
+		return "unknown"
+	}
+
+	return stateDescs[s]
+}
+
+// Finished reports whether the state is terminal
+func (s State) Finished() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCancelled
+}
+
+// MarshalText encodes the state by name
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state by name
+func (s *State) UnmarshalText(text []byte) error {
+	for i, d := range stateDescs {
+		if d == string(text) {
+			*s = State(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown state %q", string(text))
+}
+
+// Artifact produced by a builder
+type Artifact struct {
+	Builder     string   `json:"builder"`
+	BuilderID   string   `json:"builderId"`
+	ID          string   `json:"id"`
+	Region      string   `json:"region,omitempty"`
+	AMI         string   `json:"ami,omitempty"`
+	Files       []string `json:"files,omitempty"`
+	Description string   `json:"description"`
+}
+
+// Source identifies the exact template bundle a build used
+type Source struct {
+	Key      string `json:"key"`
+	Version  string `json:"version,omitempty"`
+	ETag     string `json:"etag"`
+	Checksum string `json:"checksum"`
+}
+
+// Build record
+type Build struct {
+	ID       string    `json:"id"`
+	Template string    `json:"template"`
+	Source   Source    `json:"source"`
+	Account  string    `json:"account"`
+	Regions  []string  `json:"regions"`
+	State    State     `json:"state"`
+	Created  time.Time `json:"created"`
+	Started  time.Time `json:"started"`
+	Ended    time.Time `json:"ended"`
+
+	// Errors keyed by builder name
+	Errors    map[string]string `json:"errors"`
+	Artifacts []*Artifact       `json:"artifacts"`
+
+	// LogURL is where the build's log was archived
+	LogURL string `json:"logURL,omitempty"`
+
+	// LogErrors are problems writing the build's log, they don't fail
+	// the build
+	LogErrors []string `json:"logErrors,omitempty"`
+
+	cancelRequested bool
+}
+
+// setState moves the build to state, terminal states are final
+func (b *Build) setState(state State) {
+	if b.State.Finished() {
+		return
+	}
+
+	b.State = state
+
+	switch {
+	case state == StatePreparing && b.Started.IsZero():
+		b.Started = time.Now()
+	case state.Finished():
+		b.Ended = time.Now()
+	}
+}
+
+// addArtifacts appends the artifacts not already recorded
+func (b *Build) addArtifacts(artifacts []*Artifact) {
+	for _, a := range artifacts {
+		found := false
+		for _, _a := range b.Artifacts {
+			if _a.Builder == a.Builder && _a.ID == a.ID && _a.Region == a.Region {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			b.Artifacts = append(b.Artifacts, a)
+		}
+	}
+}
+
+func (b *Build) copy() *Build {
+	c := *b
+
+	c.Errors = make(map[string]string, len(b.Errors))
+	for k, v := range b.Errors {
+		c.Errors[k] = v
+	}
+
+	c.Regions = append([]string(nil), b.Regions...)
+	c.LogErrors = append([]string(nil), b.LogErrors...)
+
+	c.Artifacts = make([]*Artifact, len(b.Artifacts))
+	for i, a := range b.Artifacts {
+		_a := *a
+		_a.Files = append([]string(nil), a.Files...)
+		c.Artifacts[i] = &_a
+	}
+
+	return &c
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hailocab/go-service-layer/config"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	// DefaultBucket S3 bucket to keep templates and logs in
+	DefaultBucket = "hailo-bakery"
+)
+
+// Init loads config and creates the default store
+func Init() {
+	conf, err := loadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	switch conf.Backend {
+	case "s3":
+		Default = NewS3Store(conf.Bucket)
+	case "local":
+		Default, err = NewLocalStore(conf.Root)
+		if err != nil {
+			panic(err)
+		}
+	default:
+		panic(fmt.Errorf("Unknown storage backend %q", conf.Backend))
+	}
+}
+
+func loadConfig() (*storageConfig, error) {
+	configJSON := config.AtPath(
+		"hailo", "service", "bakery", "storage",
+	).AsJson()
+
+	log.Debugf("Storage Config: %v", string(configJSON))
+
+	conf := storageConfig{
+		Backend: "s3",
+		Bucket:  DefaultBucket,
+	}
+
+	if err := json.Unmarshal(configJSON, &conf); err != nil {
+		return nil, err
+	}
+
+	if conf.Backend == "local" && len(conf.Root) == 0 {
+		return nil, fmt.Errorf("A root is required for local storage")
+	}
+
+	return &conf, nil
+}
+
+type storageConfig struct {
+	Backend string `json:"backend"`
+	Bucket  string `json:"bucket"`
+	Root    string `json:"root"`
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalStore keeps objects as files below Root, keys map to paths.
+// Versions aren't supported
+type LocalStore struct {
+	Root string
+}
+
+// NewLocalStore creates a store in root
+func NewLocalStore(root string) (*LocalStore, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("Unable to create storage root: %v", err)
+	}
+
+	return &LocalStore{
+		Root: root,
+	}, nil
+}
+
+// Get opens an object
+func (s *LocalStore) Get(key string, version string) (*Object, error) {
+	if len(version) > 0 {
+		return nil, fmt.Errorf("Unable to fetch %q: versions aren't supported", key)
+	}
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	etag, err := checksum(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Body: f,
+		Key:  key,
+		ETag: etag,
+	}, nil
+}
+
+// Put writes an object, replacing any existing one
+func (s *LocalStore) Put(key string, body io.ReadSeeker) (*Object, error) {
+	u, err := s.Multipart(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.Append(body); err != nil {
+		u.Abort()
+		return nil, err
+	}
+
+	return u.Complete()
+}
+
+// List returns every object below prefix
+func (s *LocalStore) List(prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.Walk(s.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Uploads in progress
+		if !info.Mode().IsRegular() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Root, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list %q: %v", prefix, err)
+	}
+
+	sort.Sort(objectsByKey(objects))
+
+	return objects, nil
+}
+
+// Multipart starts writing an object, it only appears once complete
+func (s *LocalStore) Multipart(key string) (Upload, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), ".upload-")
+	if err != nil {
+		return nil, err
+	}
+
+	return &localUpload{
+		key:  key,
+		path: path,
+		file: f,
+	}, nil
+}
+
+// URL returns the file URL of an object
+func (s *LocalStore) URL(key string) string {
+	return "file://" + filepath.ToSlash(filepath.Join(s.Root, filepath.FromSlash(key)))
+}
+
+// path maps a key below the root
+func (s *LocalStore) path(key string) (string, error) {
+	path := filepath.Join(s.Root, filepath.FromSlash(key))
+	if len(key) == 0 || !strings.HasPrefix(path, filepath.Clean(s.Root)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("Invalid key %q", key)
+	}
+
+	return path, nil
+}
+
+type localUpload struct {
+	key  string
+	path string
+	file *os.File
+}
+
+func (u *localUpload) Append(part io.ReadSeeker) error {
+	if _, err := io.Copy(u.file, part); err != nil {
+		return fmt.Errorf("Unable to write %q: %v", u.key, err)
+	}
+
+	return nil
+}
+
+func (u *localUpload) Complete() (*Object, error) {
+	if err := u.file.Close(); err != nil {
+		os.Remove(u.file.Name())
+		return nil, err
+	}
+
+	if err := os.Rename(u.file.Name(), u.path); err != nil {
+		os.Remove(u.file.Name())
+		return nil, err
+	}
+
+	etag, err := checksum(u.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Key:  u.key,
+		ETag: etag,
+	}, nil
+}
+
+func (u *localUpload) Abort() error {
+	u.file.Close()
+
+	return os.Remove(u.file.Name())
+}
+
+// checksum returns the md5 of a file, as S3 does for simple uploads
+func checksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type objectsByKey []ObjectInfo
+
+func (o objectsByKey) Len() int           { return len(o) }
+func (o objectsByKey) Less(i, j int) bool { return o[i].Key < o[j].Key }
+func (o objectsByKey) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
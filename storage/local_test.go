@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newTestStore(t *testing.T) (*LocalStore, func()) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatalf("Unable to create root: %v", err)
+	}
+
+	s, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("Unable to create store: %v", err)
+	}
+
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func TestLocalStore(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	put, err := s.Put("templates/base.zip", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("Unable to put object: %v", err)
+	}
+
+	// md5 of "hello"
+	if put.ETag != "5d41402abc4b2a76b9719d911017c592" {
+		t.Errorf("Unexpected etag %q", put.ETag)
+	}
+
+	obj, err := s.Get("templates/base.zip", "")
+	if err != nil {
+		t.Fatalf("Unable to get object: %v", err)
+	}
+
+	body, _ := ioutil.ReadAll(obj.Body)
+	obj.Body.Close()
+
+	if string(body) != "hello" || obj.ETag != put.ETag {
+		t.Errorf("Unexpected object %q with etag %q", body, obj.ETag)
+	}
+
+	if _, err := s.Get("templates/missing.zip", ""); err != ErrNotFound {
+		t.Errorf("Expected a missing object to be not found, got %v", err)
+	}
+
+	if _, err := s.Get("templates/base.zip", "abc"); err == nil {
+		t.Error("Expected versions to be rejected")
+	}
+
+	if _, err := s.Put("../escape", strings.NewReader("")); err == nil {
+		t.Error("Expected a key outside the root to be rejected")
+	}
+}
+
+func TestLocalStoreList(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, k := range []string{"templates/web/2.zip", "templates/base.zip", "logs/abc.log"} {
+		if _, err := s.Put(k, strings.NewReader(k)); err != nil {
+			t.Fatalf("Unable to put %q: %v", k, err)
+		}
+	}
+
+	// An upload in progress isn't listed
+	u, err := s.Multipart("templates/pending.zip")
+	if err != nil {
+		t.Fatalf("Unable to start upload: %v", err)
+	}
+
+	defer u.Abort()
+
+	objects, err := s.List("templates/")
+	if err != nil {
+		t.Fatalf("Unable to list: %v", err)
+	}
+
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+
+	if strings.Join(keys, ",") != "templates/base.zip,templates/web/2.zip" {
+		t.Errorf("Unexpected objects %v", keys)
+	}
+}
+
+func TestLocalStoreMultipart(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	u, err := s.Multipart("logs/abc.log")
+	if err != nil {
+		t.Fatalf("Unable to start upload: %v", err)
+	}
+
+	for _, p := range []string{"one\n", "two\n"} {
+		if err := u.Append(bytes.NewReader([]byte(p))); err != nil {
+			t.Fatalf("Unable to append: %v", err)
+		}
+	}
+
+	if _, err := s.Get("logs/abc.log", ""); err != ErrNotFound {
+		t.Errorf("Expected the object to appear once complete, got %v", err)
+	}
+
+	if _, err := u.Complete(); err != nil {
+		t.Fatalf("Unable to complete upload: %v", err)
+	}
+
+	obj, err := s.Get("logs/abc.log", "")
+	if err != nil {
+		t.Fatalf("Unable to get object: %v", err)
+	}
+
+	defer obj.Body.Close()
+
+	if body, _ := ioutil.ReadAll(obj.Body); string(body) != "one\ntwo\n" {
+		t.Errorf("Unexpected body %q", body)
+	}
+
+	aborted, err := s.Multipart("logs/def.log")
+	if err != nil {
+		t.Fatalf("Unable to start upload: %v", err)
+	}
+
+	aborted.Append(strings.NewReader("partial"))
+	if err := aborted.Abort(); err != nil {
+		t.Fatalf("Unable to abort upload: %v", err)
+	}
+
+	if objects, _ := s.List("logs/"); len(objects) != 1 {
+		t.Errorf("Expected an aborted upload to leave nothing behind, got %v", objects)
+	}
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hailocab/bakery-service/aws"
+)
+
+var (
+	// getS3ObjectVersion is replaced in tests
+	getS3ObjectVersion = aws.GetS3ObjectVersion
+)
+
+// S3Store keeps objects in an S3 bucket
+type S3Store struct {
+	Bucket string
+}
+
+// NewS3Store creates a store for bucket
+func NewS3Store(bucket string) *S3Store {
+	return &S3Store{
+		Bucket: bucket,
+	}
+}
+
+// Get fetches an object, ErrNotFound is returned if the object or version
+// doesn't exist
+func (s *S3Store) Get(key string, version string) (*Object, error) {
+	obj, err := getS3ObjectVersion(s.Bucket, key, version)
+	if _, ok := err.(*aws.NoSuchObjectError); ok {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Body:    obj.Body,
+		Key:     obj.Key,
+		Version: obj.Version,
+		ETag:    obj.ETag,
+	}, nil
+}
+
+// Put uploads an object
+func (s *S3Store) Put(key string, body io.ReadSeeker) (*Object, error) {
+	obj, err := aws.PutS3Object(s.Bucket, key, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Key:     obj.Key,
+		Version: obj.Version,
+		ETag:    obj.ETag,
+	}, nil
+}
+
+// List returns every object below prefix
+func (s *S3Store) List(prefix string) ([]ObjectInfo, error) {
+	objects, err := aws.ListS3Objects(s.Bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ObjectInfo, len(objects))
+	for i, o := range objects {
+		infos[i] = ObjectInfo{
+			Key:          o.Key,
+			Size:         o.Size,
+			LastModified: o.LastModified,
+		}
+	}
+
+	return infos, nil
+}
+
+// Multipart starts a multipart upload. Every part but the last must be
+// at least 5MB
+func (s *S3Store) Multipart(key string) (Upload, error) {
+	id, err := aws.CreateMultipartUpload(s.Bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Upload{
+		bucket: s.Bucket,
+		key:    key,
+		id:     id,
+	}, nil
+}
+
+// URL returns the S3 URL of an object
+func (s *S3Store) URL(key string) string {
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, key)
+}
+
+type s3Upload struct {
+	bucket string
+	key    string
+	id     string
+	parts  []aws.S3Part
+}
+
+func (u *s3Upload) Append(part io.ReadSeeker) error {
+	n := int64(len(u.parts) + 1)
+
+	etag, err := aws.UploadPart(u.bucket, u.key, u.id, n, part)
+	if err != nil {
+		return err
+	}
+
+	u.parts = append(u.parts, aws.S3Part{
+		Number: n,
+		ETag:   etag,
+	})
+
+	return nil
+}
+
+func (u *s3Upload) Complete() (*Object, error) {
+	obj, err := aws.CompleteMultipartUpload(u.bucket, u.key, u.id, u.parts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Key:     obj.Key,
+		Version: obj.Version,
+		ETag:    obj.ETag,
+	}, nil
+}
+
+func (u *s3Upload) Abort() error {
+	return aws.AbortMultipartUpload(u.bucket, u.key, u.id)
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/hailocab/bakery-service/aws"
+)
+
+// withFakeS3 replaces fetching objects with fn, returning a func restoring it
+func withFakeS3(fn func(bucket, key, version string) (*aws.S3Object, error)) func() {
+	orig := getS3ObjectVersion
+	getS3ObjectVersion = fn
+
+	return func() { getS3ObjectVersion = orig }
+}
+
+func TestS3StoreGet(t *testing.T) {
+	defer withFakeS3(func(bucket, key, version string) (*aws.S3Object, error) {
+		return &aws.S3Object{
+			Body:    ioutil.NopCloser(strings.NewReader("hello")),
+			Key:     key,
+			Version: "v1",
+			ETag:    "etag",
+		}, nil
+	})()
+
+	obj, err := NewS3Store("bucket").Get("templates/base.zip", "")
+	if err != nil {
+		t.Fatalf("Unable to get: %v", err)
+	}
+
+	if obj.Key != "templates/base.zip" || obj.Version != "v1" || obj.ETag != "etag" {
+		t.Errorf("Unexpected object %#v", obj)
+	}
+}
+
+func TestS3StoreGetNotFound(t *testing.T) {
+	defer withFakeS3(func(bucket, key, version string) (*aws.S3Object, error) {
+		return nil, &aws.NoSuchObjectError{Bucket: bucket, Key: key, Version: version}
+	})()
+
+	for _, version := range []string{"", "v2"} {
+		if _, err := NewS3Store("bucket").Get("logs/abc.log", version); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for version %q, got %v", version, err)
+		}
+	}
+}
+
+func TestS3StoreGetError(t *testing.T) {
+	denied := fmt.Errorf("Unable to fetch 'bucket/logs/abc.log': AccessDenied")
+
+	defer withFakeS3(func(bucket, key, version string) (*aws.S3Object, error) {
+		return nil, denied
+	})()
+
+	if _, err := NewS3Store("bucket").Get("logs/abc.log", ""); err != denied {
+		t.Errorf("Expected other errors to be returned as is, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// Default store for templates and logs
+	Default Store
+
+	// ErrNotFound is returned for objects that don't exist
+	ErrNotFound = fmt.Errorf("Object not found")
+)
+
+// Store holds templates and build logs
+type Store interface {
+	// Get returns a version of an object, the latest if version is empty
+	Get(key string, version string) (*Object, error)
+
+	// Put writes an object
+	Put(key string, body io.ReadSeeker) (*Object, error)
+
+	// List returns every object below prefix, sorted by key
+	List(prefix string) ([]ObjectInfo, error)
+
+	// Multipart starts writing an object in parts
+	Multipart(key string) (Upload, error)
+
+	// URL returns where an object can be found
+	URL(key string) string
+}
+
+// Upload is an object being written in parts
+type Upload interface {
+	// Append writes the next part of the object
+	Append(part io.ReadSeeker) error
+
+	// Complete assembles the parts into the object
+	Complete() (*Object, error)
+
+	// Abort discards the parts
+	Abort() error
+}
+
+// Object is the body of an object along with its metadata, Body is only
+// set by Get
+type Object struct {
+	Body    io.ReadCloser
+	Key     string
+	Version string
+	ETag    string
+}
+
+// ObjectInfo describes an object without its body
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
@@ -0,0 +1,198 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hailocab/go-service-layer/config"
+
+	log "github.com/cihub/seelog"
+)
+
+const (
+	// DefaultRetention how long failed workspaces are kept if not configured
+	DefaultRetention = time.Hour * 24
+
+	// SweepInterval how often expired workspaces are looked for
+	SweepInterval = time.Hour
+
+	// failedMarker is written to workspaces kept for debugging
+	failedMarker = ".failed"
+)
+
+var (
+	// Default workspace manager used by the handlers
+	Default *Manager
+)
+
+// Manager owns the working directories of builds. Each build gets a
+// directory named after its ID below Root
+type Manager struct {
+	sync.Mutex
+
+	Root      string
+	Retention time.Duration
+
+	active map[string]bool
+}
+
+// New creates a manager, creating root if needed
+func New(root string, retention time.Duration) (*Manager, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("Unable to create workspace root: %v", err)
+	}
+
+	return &Manager{
+		Root:      root,
+		Retention: retention,
+		active:    map[string]bool{},
+	}, nil
+}
+
+// Path returns the directory of a workspace
+func (m *Manager) Path(id string) string {
+	return filepath.Join(m.Root, id)
+}
+
+// Create makes a new workspace for a build
+func (m *Manager) Create(id string) (string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	dir := m.Path(id)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", fmt.Errorf("Unable to create workspace: %v", err)
+	}
+
+	m.active[id] = true
+
+	return dir, nil
+}
+
+// Release is called when a build ends. Workspaces of failed builds are
+// kept for the retention period, everything else is removed
+func (m *Manager) Release(id string, failed bool) error {
+	m.Lock()
+	defer m.Unlock()
+
+	delete(m.active, id)
+
+	if failed && m.Retention > 0 {
+		log.Infof("Keeping workspace %s for %v", m.Path(id), m.Retention)
+		return ioutil.WriteFile(filepath.Join(m.Path(id), failedMarker), []byte(time.Now().Format(time.RFC3339)), 0644)
+	}
+
+	return os.RemoveAll(m.Path(id))
+}
+
+// Usage returns the number of bytes used by a workspace
+func (m *Manager) Usage(id string) (int64, error) {
+	var size int64
+
+	err := filepath.Walk(m.Path(id), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
+
+// Sweep removes workspaces that aren't in use. Failed workspaces are
+// only removed once they've been kept for the retention period
+func (m *Manager) Sweep() error {
+	m.Lock()
+	defer m.Unlock()
+
+	entries, err := ioutil.ReadDir(m.Root)
+	if err != nil {
+		return fmt.Errorf("Unable to list workspaces: %v", err)
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() || m.active[e.Name()] {
+			continue
+		}
+
+		dir := filepath.Join(m.Root, e.Name())
+
+		if fi, err := os.Stat(filepath.Join(dir, failedMarker)); err == nil && time.Since(fi.ModTime()) < m.Retention {
+			continue
+		}
+
+		log.Infof("Removing workspace %s", dir)
+		if err := os.RemoveAll(dir); err != nil {
+			log.Errorf("Unable to remove workspace %s: %v", dir, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) sweep(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := m.Sweep(); err != nil {
+			log.Errorf("Workspace sweep failed: %v", err)
+		}
+	}
+}
+
+// Init loads config, removes workspaces left behind by previous runs
+// and starts sweeping expired ones
+func Init() {
+	conf, err := loadConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	retention, err := time.ParseDuration(conf.Retention)
+	if err != nil {
+		panic(fmt.Errorf("Invalid workspace retention %q: %v", conf.Retention, err))
+	}
+
+	Default, err = New(conf.Root, retention)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := Default.Sweep(); err != nil {
+		panic(err)
+	}
+
+	go Default.sweep(SweepInterval)
+}
+
+func loadConfig() (*workspaceConfig, error) {
+	configJSON := config.AtPath(
+		"hailo", "service", "bakery", "workspace",
+	).AsJson()
+
+	log.Debugf("Workspace Config: %v", string(configJSON))
+
+	conf := workspaceConfig{
+		Root:      filepath.Join(os.TempDir(), "bakery"),
+		Retention: DefaultRetention.String(),
+	}
+
+	if err := json.Unmarshal(configJSON, &conf); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+type workspaceConfig struct {
+	Root      string `json:"root"`
+	Retention string `json:"retention"`
+}
@@ -0,0 +1,64 @@
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLifecycle(t *testing.T) {
+	root, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	m, err := New(root, time.Hour)
+	if err != nil {
+		t.Fatalf("Unable to create manager: %v", err)
+	}
+
+	for _, id := range []string{"ok", "failed", "running"} {
+		dir, err := m.Create(id)
+		if err != nil {
+			t.Fatalf("Unable to create workspace: %v", err)
+		}
+
+		ioutil.WriteFile(filepath.Join(dir, "archive.zip"), []byte("12345"), 0644)
+	}
+
+	if size, err := m.Usage("ok"); err != nil || size != 5 {
+		t.Fatalf("Expected 5 bytes used, got %d: %v", size, err)
+	}
+
+	m.Release("ok", false)
+	m.Release("failed", true)
+
+	// Left behind by a crashed run
+	os.Mkdir(filepath.Join(root, "orphan"), 0755)
+
+	if err := m.Sweep(); err != nil {
+		t.Fatalf("Unable to sweep: %v", err)
+	}
+
+	for id, exists := range map[string]bool{
+		"ok":      false,
+		"failed":  true,
+		"running": true,
+		"orphan":  false,
+	} {
+		if _, err := os.Stat(m.Path(id)); (err == nil) != exists {
+			t.Errorf("Expected %q to exist: %v", id, exists)
+		}
+	}
+
+	// Once retention passes failed workspaces go too
+	m.Retention = 0
+	m.Sweep()
+
+	if _, err := os.Stat(m.Path("failed")); !os.IsNotExist(err) {
+		t.Error("Expired workspace wasn't removed")
+	}
+}